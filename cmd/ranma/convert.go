@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/japanoise/ranma"
+)
+
+func cmdConvert(args []string) {
+	fs := newFlags("convert")
+	from := fs.String("from", "bc", "ordering the numbers are in")
+	to := fs.String("to", "", "comma-separated orderings to convert to (default all others)")
+	args = parseFlags(fs, args)
+	requiresArgs(args, 1)
+	table := loadTable()
+
+	fromOrder, err := ranma.ParseOrder(*from)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+
+	var toOrders []ranma.Order
+	if *to == "" {
+		for _, o := range ranma.Orders {
+			if o != fromOrder {
+				toOrders = append(toOrders, o)
+			}
+		}
+	} else {
+		for _, name := range strings.Split(*to, ",") {
+			o, err := ranma.ParseOrder(strings.TrimSpace(name))
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(-1)
+			}
+			toOrders = append(toOrders, o)
+		}
+	}
+
+	_, last := table.Range(fromOrder)
+	nums, err := parseNumbers(strings.Join(args, ","), last)
+	if err != nil {
+		fmt.Printf("Bad argument: %v\n", err)
+		os.Exit(-1)
+	}
+
+	found, missing := findNumbers(table, fromOrder, nums)
+
+	if opts.format != "" || opts.template != "" || opts.templateFile != "" {
+		show(found, false)
+	} else {
+		row := []string{fromOrder.Short()}
+		for _, o := range toOrders {
+			row = append(row, o.Short())
+		}
+		fmt.Println(strings.Join(row, "\t"))
+		for _, epi := range found {
+			row = row[:0]
+			for _, o := range append([]ranma.Order{fromOrder}, toOrders...) {
+				row = append(row, orderCell(o, epi))
+			}
+			fmt.Println(strings.Join(row, "\t"))
+		}
+	}
+
+	if len(missing) > 0 {
+		for _, n := range missing {
+			fmt.Printf("Can't find %s episode %d\n", fromOrder, n)
+		}
+		explainRange(table, fromOrder)
+		os.Exit(-1)
+	}
+}
+
+// orderCell is an episode's number in an ordering, or "-" if it has none.
+func orderCell(o ranma.Order, epi ranma.Episode) string {
+	if n := o.Number(epi); n > 0 {
+		return strconv.Itoa(n)
+	}
+	return "-"
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/japanoise/ranma"
+)
+
+// overlayPath returns the overlay file to use, and whether the user asked
+// for it explicitly (in which case it must exist).
+func overlayPath() (string, bool) {
+	if opts.data != "" {
+		return opts.data, true
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", false
+	}
+	return filepath.Join(dir, "ranma", "episodes.json"), false
+}
+
+// loadTables returns the built-in episode table and the table with the
+// user's overlay applied. If there is no overlay, both are the same.
+func loadTables() (*ranma.Table, *ranma.Table) {
+	base, err := ranma.Builtin()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+
+	path, explicit := overlayPath()
+	if path == "" {
+		return base, base
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return base, base
+		}
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+	defer f.Close()
+
+	overlay, err := ranma.LoadOverlay(f)
+	if err != nil {
+		fmt.Printf("%s: %v\n", path, err)
+		os.Exit(-1)
+	}
+
+	merged, err := base.Apply(overlay)
+	if err != nil {
+		fmt.Printf("%s: %v\n", path, err)
+		os.Exit(-1)
+	}
+
+	return base, merged
+}
+
+// loadTable returns the episode table every command works on.
+func loadTable() *ranma.Table {
+	_, table := loadTables()
+	return table
+}
+
+func cmdData(args []string) {
+	args = parseFlags(newFlags("data"), args)
+	requiresArgs(args, 1)
+
+	switch args[0] {
+	case "diff":
+		base, merged := loadTables()
+		changes := ranma.Diff(base, merged)
+		if len(changes) == 0 {
+			path, _ := overlayPath()
+			fmt.Printf("No changes from overlay %s\n", path)
+			return
+		}
+		for _, c := range changes {
+			switch {
+			case c.Field != "":
+				fmt.Printf("Production %d %s: %q -> %q\n", c.Production, c.Field, c.Old, c.New)
+			case c.Old == "":
+				fmt.Printf("Production %d added: %q\n", c.Production, c.New)
+			default:
+				fmt.Printf("Production %d removed: %q\n", c.Production, c.Old)
+			}
+		}
+	default:
+		fmt.Printf("Unknown data command %s\n", args[0])
+		os.Exit(-1)
+	}
+}
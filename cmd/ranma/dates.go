@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/japanoise/ranma"
+)
+
+func parseDateArg(s string) time.Time {
+	date, err := ranma.ParseDate(s)
+	if err != nil {
+		fmt.Printf("Bad date %s: want YYYY-MM-DD\n", s)
+		os.Exit(-1)
+	}
+	return date
+}
+
+// showAired prints the episodes of a date query, or explains that there
+// weren't any.
+func showAired(list []ranma.Episode, when string) {
+	if len(list) == 0 {
+		fmt.Printf("No episodes first aired %s\n", when)
+		os.Exit(-1)
+	}
+	show(list, false)
+}
+
+func cmdDate(args []string) {
+	args = parseFlags(newFlags("date"), args)
+	requiresArgs(args, 1)
+	table := loadTable()
+
+	date := parseDateArg(args[0])
+	showAired(table.AiredBetween(date, date), "on "+ranma.JPDate(date))
+}
+
+func cmdAired(args []string) {
+	fs := newFlags("aired")
+	from := fs.String("from", "", "first date (YYYY-MM-DD) to include")
+	to := fs.String("to", "", "last date (YYYY-MM-DD) to include")
+	parseFlags(fs, args)
+	table := loadTable()
+
+	if *from == "" && *to == "" {
+		fmt.Println("This command requires --from, --to or both")
+		os.Exit(-1)
+	}
+
+	first, last := time.Time{}, time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	if *from != "" {
+		first = parseDateArg(*from)
+	}
+	if *to != "" {
+		last = parseDateArg(*to)
+	}
+	showAired(table.AiredBetween(first, last), "in that period")
+}
+
+func cmdYear(args []string) {
+	args = parseFlags(newFlags("year"), args)
+	requiresArgs(args, 1)
+	table := loadTable()
+
+	year, err := strconv.Atoi(args[0])
+	if err != nil {
+		fmt.Printf("Bad argument: %v\n", err)
+		os.Exit(-1)
+	}
+
+	first := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	last := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+	showAired(table.AiredBetween(first, last), "in "+args[0])
+}
+
+// cmdToday lists the episodes first aired on today's date in any year, or
+// on the month and day given as MM-DD.
+func cmdToday(args []string) {
+	args = parseFlags(newFlags("today"), args)
+	table := loadTable()
+
+	day := time.Now()
+	if len(args) > 0 {
+		var err error
+		day, err = time.Parse("01-02", args[0])
+		if err != nil {
+			fmt.Printf("Bad date %s: want MM-DD\n", args[0])
+			os.Exit(-1)
+		}
+	}
+
+	showAired(table.AiredOnDay(day.Month(), day.Day()), "on "+day.Format("January 2"))
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/japanoise/ranma"
+)
+
+// episodeSorts are the keys accepted by "episodes --sort".
+var episodeSorts = map[string]func(a, b ranma.Episode) bool{
+	"date": func(a, b ranma.Episode) bool { return a.Date().Before(b.Date()) },
+	"name": func(a, b ranma.Episode) bool { return ranma.Fold(a.Name()) < ranma.Fold(b.Name()) },
+}
+
+func init() {
+	for _, o := range ranma.Orders {
+		o := o
+		less := func(a, b ranma.Episode) bool { return o.Number(a) < o.Number(b) }
+		episodeSorts[o.Short()] = less
+		episodeSorts[strings.ToLower(o.String())] = less
+	}
+}
+
+func cmdEpisodes(args []string) {
+	fs := newFlags("episodes")
+	series := fs.String("series", "", "only list the original or nettohen series")
+	ranges := make(map[ranma.Order]*string)
+	for _, o := range ranma.Orders {
+		ranges[o] = fs.String(o.Short(), "", "only list these "+o.String()+" numbers, e.g. 1-50")
+	}
+	after := fs.String("aired-after", "", "only list episodes first aired after this date (YYYY-MM-DD)")
+	before := fs.String("aired-before", "", "only list episodes first aired before this date (YYYY-MM-DD)")
+	contains := fs.String("title-contains", "", "only list episodes with this text in any title")
+	reordered := fs.Bool("reordered", false, "only list episodes whose broadcast, Viz and production numbers disagree")
+	sortBy := fs.String("sort", "", "sort by nh, bc, viz, prod, date or name")
+	reverse := fs.Bool("reverse", false, "reverse the order")
+	parseFlags(fs, args)
+	table := loadTable()
+
+	var filters []func(ranma.Episode) bool
+
+	switch *series {
+	case "":
+	case "original", "og":
+		filters = append(filters, func(e ranma.Episode) bool { return !e.IsNettohen() })
+	case "nettohen", "nh":
+		filters = append(filters, ranma.Episode.IsNettohen)
+	default:
+		fmt.Printf("Unknown series %s (want original or nettohen)\n", *series)
+		os.Exit(-1)
+	}
+
+	for _, o := range ranma.Orders {
+		if *ranges[o] == "" {
+			continue
+		}
+		_, last := table.Range(o)
+		nums, err := parseNumbers(*ranges[o], last)
+		if err != nil {
+			fmt.Printf("Bad --%s: %v\n", o.Short(), err)
+			os.Exit(-1)
+		}
+		wanted := make(map[int]bool, len(nums))
+		for _, n := range nums {
+			wanted[n] = true
+		}
+		o := o
+		filters = append(filters, func(e ranma.Episode) bool { return wanted[o.Number(e)] })
+	}
+
+	if *after != "" {
+		date := parseDateArg(*after)
+		filters = append(filters, func(e ranma.Episode) bool { return e.Date().After(date) })
+	}
+	if *before != "" {
+		date := parseDateArg(*before)
+		filters = append(filters, func(e ranma.Episode) bool { return e.Date().Before(date) })
+	}
+
+	if *contains != "" {
+		filters = append(filters, func(e ranma.Episode) bool {
+			for _, f := range ranma.Fields {
+				if f.Contains(e, *contains) {
+					return true
+				}
+			}
+			return false
+		})
+	}
+
+	if *reordered {
+		filters = append(filters, ranma.Episode.Reordered)
+	}
+
+	list := table.Filter(func(e ranma.Episode) bool {
+		for _, f := range filters {
+			if !f(e) {
+				return false
+			}
+		}
+		return true
+	})
+
+	if *sortBy != "" {
+		less, ok := episodeSorts[strings.ToLower(*sortBy)]
+		if !ok {
+			fmt.Printf("Unknown sort %s (want nh, bc, viz, prod, date or name)\n", *sortBy)
+			os.Exit(-1)
+		}
+		sort.SliceStable(list, func(i, j int) bool { return less(list[i], list[j]) })
+	}
+	if *reverse {
+		for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+			list[i], list[j] = list[j], list[i]
+		}
+	}
+
+	if opts.format == "" {
+		opts.format = "tsv"
+	}
+	show(list, false)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func cmdExport(args []string) {
+	fs := newFlags("export")
+	orderName := fs.String("order", "bc", "nfo: numbering to use: bc, viz or seasons")
+	force := fs.Bool("force", false, "nfo: replace existing NFO files")
+	recursive := fs.Bool("r", false, "nfo: look in subdirectories too")
+	output := fs.String("o", "", "ics: file to write to (default standard output)")
+	anniversary := fs.Bool("anniversary", false, "ics: make each event recur every year")
+	args = parseFlags(fs, args)
+	requiresArgs(args, 1)
+
+	switch args[0] {
+	case "nfo":
+		if len(args) != 2 {
+			fmt.Println("export nfo takes one directory")
+			os.Exit(-1)
+		}
+		exportNFO(args[1], *orderName, *recursive, *force)
+	case "ics":
+		writeICS(*output, *anniversary)
+	default:
+		fmt.Printf("Unknown export format %s\n", args[0])
+		os.Exit(-1)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// Options that are accepted by every command, before or after its name.
+var opts struct {
+	data    string
+	state   string
+	profile string
+	format  string
+	columns string
+
+	template     string
+	templateFile string
+}
+
+func addGlobalFlags(fs *flag.FlagSet) {
+	fs.StringVar(&opts.data, "data", opts.data, "episode overlay file (default $XDG_CONFIG_HOME/ranma/episodes.json)")
+	fs.StringVar(&opts.state, "state", opts.state, "watch state file (default $XDG_DATA_HOME/ranma/watched.json)")
+	fs.StringVar(&opts.profile, "profile", opts.profile, "whose watch progress to use (default \"default\")")
+	fs.StringVar(&opts.format, "format", opts.format, "output format: text, json, jsonl, tsv, csv, yaml or markdown")
+	fs.StringVar(&opts.columns, "columns", opts.columns, "comma-separated columns for tsv, csv, yaml and markdown output")
+	fs.StringVar(&opts.template, "template", opts.template, "Go text/template to print each episode with")
+	fs.StringVar(&opts.templateFile, "template-file", opts.templateFile, "file to read the --template from")
+}
+
+// newFlags returns a flag set for the named command with the global options
+// already registered.
+func newFlags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	addGlobalFlags(fs)
+	return fs
+}
+
+// parseFlags parses args with fs, allowing flags to be mixed in with the
+// positional arguments, which are returned. "--" ends flag parsing.
+func parseFlags(fs *flag.FlagSet, args []string) []string {
+	var pos []string
+	for len(args) > 0 {
+		if err := fs.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				os.Exit(0)
+			}
+			os.Exit(-1)
+		}
+		consumed := len(args) - fs.NArg()
+		rest := fs.Args()
+		if consumed > 0 && args[consumed-1] == "--" {
+			return append(pos, rest...)
+		}
+		if len(rest) == 0 {
+			break
+		}
+		pos = append(pos, rest[0])
+		args = rest[1:]
+	}
+	return pos
+}
+
+// requiresArgs exits unless at least n positional arguments were given.
+func requiresArgs(args []string, n int) {
+	if len(args) < n {
+		if n == 1 {
+			fmt.Println("This command requires at least one argument")
+		} else {
+			fmt.Printf("This command requires at least %d arguments\n", n)
+		}
+		os.Exit(-1)
+	}
+}
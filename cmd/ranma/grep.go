@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/japanoise/ranma"
+)
+
+const (
+	highlightStart = "\x1b[1;31m"
+	highlightEnd   = "\x1b[0m"
+)
+
+// useColor decides whether to highlight output for --color=auto|always|never.
+func useColor(mode string) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func cmdGrep(args []string) {
+	fs := newFlags("grep")
+	ignoreCase := fs.Bool("i", false, "ignore case")
+	fieldList := fs.String("field", "name,rjname,jpname", "comma-separated title fields to search")
+	color := fs.String("color", "auto", "highlight matches: auto, always or never")
+	args = parseFlags(fs, args)
+	requiresArgs(args, 1)
+	table := loadTable()
+
+	var fields []ranma.Field
+	for _, name := range strings.Split(*fieldList, ",") {
+		f, err := ranma.ParseField(strings.TrimSpace(name))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		fields = append(fields, f)
+	}
+
+	expr := strings.Join(args, " ")
+	if *ignoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		fmt.Printf("Bad pattern: %v\n", err)
+		os.Exit(-1)
+	}
+
+	list := table.Filter(func(e ranma.Episode) bool {
+		for _, f := range fields {
+			if re.MatchString(f.Text(e)) {
+				return true
+			}
+		}
+		return false
+	})
+	if len(list) == 0 {
+		fmt.Println("No episodes match")
+		os.Exit(-1)
+	}
+
+	if opts.format != "" || opts.template != "" || opts.templateFile != "" {
+		show(list, false)
+		return
+	}
+
+	highlight := useColor(*color)
+	for _, epi := range list {
+		for _, f := range fields {
+			text := f.Text(epi)
+			if !re.MatchString(text) {
+				continue
+			}
+			if highlight {
+				text = re.ReplaceAllStringFunc(text, func(m string) string {
+					// Highlighting an empty match would only add noise.
+					if m == "" {
+						return m
+					}
+					return highlightStart + m + highlightEnd
+				})
+			}
+			fmt.Printf("Broadcast %d\t%s\t%s\n", epi.Broadcast(), f, text)
+		}
+	}
+}
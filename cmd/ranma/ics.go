@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/japanoise/ranma"
+)
+
+// icsEscaper escapes TEXT values as RFC 5545 requires.
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// icsWriter writes iCalendar content lines, folded to 75 octets and ended
+// with CRLF.
+type icsWriter struct {
+	w *bufio.Writer
+}
+
+func (iw icsWriter) line(name, value string) {
+	s := name + ":" + value
+	limit := 75
+	for len(s) > limit {
+		// Don't split a UTF-8 sequence across lines.
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		iw.w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		limit = 74 // the leading space counts
+	}
+	iw.w.WriteString(s + "\r\n")
+}
+
+// exportICS writes a calendar with an all-day event on each episode's first
+// air date, or, with anniversary set, one recurring every year.
+func exportICS(w io.Writer, anniversary bool) error {
+	table := loadTable()
+	iw := icsWriter{bufio.NewWriter(w)}
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	iw.line("BEGIN", "VCALENDAR")
+	iw.line("VERSION", "2.0")
+	iw.line("PRODID", "-//japanoise//ranma//EN")
+	iw.line("CALSCALE", "GREGORIAN")
+	iw.line("X-WR-CALNAME", icsEscaper.Replace("Ranma ½ air dates"))
+	for _, epi := range table.Sorted(ranma.OrderBroadcast) {
+		day := epi.Date()
+		iw.line("BEGIN", "VEVENT")
+		iw.line("UID", fmt.Sprintf("production-%d@ranma.japanoise", epi.Production()))
+		iw.line("DTSTAMP", stamp)
+		iw.line("DTSTART;VALUE=DATE", day.Format("20060102"))
+		iw.line("DTEND;VALUE=DATE", day.AddDate(0, 0, 1).Format("20060102"))
+		if anniversary {
+			iw.line("RRULE", "FREQ=YEARLY")
+		}
+		iw.line("SUMMARY", icsEscaper.Replace(fmt.Sprintf("Broadcast %d: %s", epi.Broadcast(), epi.Name())))
+		iw.line("DESCRIPTION", icsEscaper.Replace(icsDescription(epi)))
+		iw.line("TRANSP", "TRANSPARENT")
+		iw.line("END", "VEVENT")
+	}
+	iw.line("END", "VCALENDAR")
+	return iw.w.Flush()
+}
+
+func icsDescription(epi ranma.Episode) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n%s\n\n", epi.JapaneseName(), epi.RomajiName())
+	if epi.IsNettohen() {
+		fmt.Fprintf(&b, "Nettohen %d\n", epi.Nettohen())
+	}
+	fmt.Fprintf(&b, "Viz %d\nProduction %d\nFirst aired %s", epi.Viz(), epi.Production(), ranma.JPDate(epi.Date()))
+	if epi.Notes() != "" {
+		fmt.Fprintf(&b, "\n\n%s", epi.Notes())
+	}
+	return b.String()
+}
+
+func writeICS(output string, anniversary bool) {
+	var w io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := exportICS(w, anniversary); err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/japanoise/ranma"
+)
+
+func cmdRomajiName(args []string) {
+	searchTitle("rjname", "romaji name", ranma.FieldRomaji, args)
+}
+
+func cmdJapaneseName(args []string) {
+	searchTitle("jpname", "Japanese name", ranma.FieldJapanese, args)
+}
+
+func cmdName(args []string) {
+	searchTitle("name", "name", ranma.FieldName, args)
+}
+
+// searchTitle prints the episode whose title best matches the arguments. If
+// the match is ambiguous, the candidates are listed with their scores.
+func searchTitle(cmd, what string, field ranma.Field, args []string) {
+	fs := newFlags(cmd)
+	limit := fs.Int("limit", 5, "maximum number of candidates to list")
+	minScore := fs.Float64("min-score", 0.5, "minimum score (0-1) for a candidate")
+	args = parseFlags(fs, args)
+	requiresArgs(args, 1)
+	table := loadTable()
+	arg := strings.Join(args, " ")
+
+	var matches []ranma.Match
+	for _, m := range table.Search(arg, field) {
+		if m.Score >= *minScore {
+			matches = append(matches, m)
+		}
+	}
+	if len(matches) == 0 {
+		fmt.Printf("Can't find episode %s \"%s\"\n", what, arg)
+		suggestTitles(table, arg, field)
+		os.Exit(-1)
+	}
+
+	if clearWinner(matches) {
+		show([]ranma.Episode{matches[0].Episode}, true)
+		return
+	}
+
+	total := len(matches)
+	if *limit > 0 && len(matches) > *limit {
+		matches = matches[:*limit]
+	}
+
+	if opts.format != "" || opts.template != "" || opts.templateFile != "" {
+		list := make([]ranma.Episode, len(matches))
+		for i, m := range matches {
+			list[i] = m.Episode
+		}
+		show(list, false)
+		return
+	}
+
+	if total == 1 {
+		fmt.Printf("The closest match for \"%s\" is:\n", arg)
+	} else if total > len(matches) {
+		fmt.Printf("%d episodes match \"%s\"; the best %d are:\n", total, arg, len(matches))
+	} else {
+		fmt.Printf("%d episodes match \"%s\":\n", total, arg)
+	}
+	for _, m := range matches {
+		fmt.Printf("%.2f\tBroadcast %d\t%s\n", m.Score, m.Episode.Broadcast(), field.Text(m.Episode))
+	}
+}
+
+// clearWinner reports whether the best of a ranked list of matches can be
+// taken as the answer: an exact match with no other, or a close match well
+// ahead of the runner-up.
+func clearWinner(matches []ranma.Match) bool {
+	best := matches[0].Score
+	next := 0.0
+	if len(matches) > 1 {
+		next = matches[1].Score
+	}
+	if best == 1 {
+		return next < 1
+	}
+	return best >= 0.85 && next < best-0.1
+}
+
+func cmdNettohen(args []string) {
+	lookupNumber("nettohen", ranma.OrderNettohen, args)
+}
+
+func cmdBroadcast(args []string) {
+	lookupNumber("broadcast", ranma.OrderBroadcast, args)
+}
+
+func cmdViz(args []string) {
+	lookupNumber("viz", ranma.OrderViz, args)
+}
+
+func cmdProduction(args []string) {
+	lookupNumber("production", ranma.OrderProduction, args)
+}
+
+// lookupNumber prints the episodes with the given numbers in an ordering.
+// Arguments may be lists and ranges, e.g. "1,5,9-12" or "100-".
+func lookupNumber(cmd string, order ranma.Order, args []string) {
+	args = parseFlags(newFlags(cmd), args)
+	requiresArgs(args, 1)
+	table := loadTable()
+
+	spec := strings.Join(args, ",")
+	_, last := table.Range(order)
+	nums, err := parseNumbers(spec, last)
+	if err != nil {
+		fmt.Printf("Bad argument: %v\n", err)
+		os.Exit(-1)
+	}
+
+	found, missing := findNumbers(table, order, nums)
+	single := len(nums) == 1 && !strings.ContainsAny(spec, ",-")
+	if len(found) > 0 {
+		show(found, single)
+	}
+
+	if len(missing) > 0 {
+		for _, n := range missing {
+			fmt.Printf("Can't find %s episode %d\n", order, n)
+		}
+		explainRange(table, order)
+		os.Exit(-1)
+	}
+}
+
+// findNumbers looks up each number in an ordering, returning the episodes
+// found and the numbers that weren't.
+func findNumbers(table *ranma.Table, order ranma.Order, nums []int) ([]ranma.Episode, []int) {
+	var found []ranma.Episode
+	var missing []int
+	for _, n := range nums {
+		epi, err := table.By(order, n)
+		if err != nil {
+			missing = append(missing, n)
+			continue
+		}
+		found = append(found, *epi)
+	}
+	return found, missing
+}
+
+// explainRange tells the user which numbers are valid in an ordering.
+func explainRange(table *ranma.Table, order ranma.Order) {
+	first, last := table.Range(order)
+	name := order.String()
+	fmt.Printf("%s%s episodes are numbered %d–%d\n", strings.ToUpper(name[:1]), name[1:], first, last)
+}
+
+// suggestTitles prints the titles closest to a query that found nothing.
+func suggestTitles(table *ranma.Table, query string, field ranma.Field) {
+	var suggestions []ranma.Match
+	for _, m := range table.Suggest(query, field, 3) {
+		// Anything further off than this is just noise.
+		if m.Score >= 0.6 {
+			suggestions = append(suggestions, m)
+		}
+	}
+	if len(suggestions) == 0 {
+		return
+	}
+	fmt.Println("Did you mean:")
+	for _, m := range suggestions {
+		fmt.Printf("\t%s (broadcast %d)\n", field.Text(m.Episode), m.Episode.Broadcast())
+	}
+}
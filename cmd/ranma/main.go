@@ -0,0 +1,171 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func usage() {
+	fmt.Printf("%s: Ranma ½ episode search utility\n\nUsage: %s [OPTION]... <COMMAND> [ARG]...\n", os.Args[0], os.Args[0])
+	fmt.Println("\nCommands are:")
+	fmt.Println("\tnettohen\t(Alias \"nh\") Find episode by Nettohen number.")
+	fmt.Println("\tbroadcast\t(Alias \"bc\") Find episode by broadcast order.")
+	fmt.Println("\tproduction\t(Alias \"prod\") Find episode by production order.")
+	fmt.Println("\tviz\tFind episode by Viz home release order.")
+	fmt.Println("\t\t\tThese four take lists and ranges too, e.g. \"bc 10-20\", \"nh 1,5,9-12\"")
+	fmt.Println("\t\t\tor \"viz 100-\".")
+	fmt.Println("\tname\tFind episode by English name (fuzzy find)")
+	fmt.Println("\trjname\tFind episode by Japanese (romaji) name (fuzzy find, any")
+	fmt.Println("\t\t\tHepburn spelling: Ryōga, Ryouga and Ryoga all match)")
+	fmt.Println("\tjpname\tFind episode by Japanese name in kanji/kana (fuzzy find)")
+	fmt.Println("\t\t\tAll three list the best candidates unless one clearly matches;")
+	fmt.Println("\t\t\t--limit N and --min-score S (0-1) control how many.")
+	fmt.Println("\tgrep\tSearch titles with a regular expression. -i ignores case, --field")
+	fmt.Println("\t\t\tpicks from name, rjname and jpname, and --color=auto|always|never")
+	fmt.Println("\t\t\tcontrols highlighting.")
+	fmt.Println("\tquery\tFind episodes matching a query, e.g. 'bc>=40 and bc<60 and title~\"Happosai\"'.")
+	fmt.Println("\t\t\tFields: nh, bc, viz, prod, year, date, series, name, rjname, jpname,")
+	fmt.Println("\t\t\tnotes and title (any title). Operators: = != < <= > >=, and ~ !~ with")
+	fmt.Println("\t\t\ta \"string\" (substring) or /regexp/. Combine with and, or, not and ().")
+	fmt.Println("\tdate\tFind episodes first aired on a date (YYYY-MM-DD).")
+	fmt.Println("\taired\tList episodes aired --from and/or --to a date (YYYY-MM-DD).")
+	fmt.Println("\tyear\tList episodes aired in a year.")
+	fmt.Println("\ttoday\tList episodes first aired on today's date, or on MM-DD, in any year.")
+	fmt.Println("\torder\tList every episode in an ordering (nh, bc, viz or prod); --playlist")
+	fmt.Println("\t\t\tprints one \"number<TAB>title\" per line. \"order diff bc viz\" shows")
+	fmt.Println("\t\t\twhere two orderings diverge and by how many positions.")
+	fmt.Println("\tnext\tShow the episode after another in an ordering, e.g. \"next --order viz 40\".")
+	fmt.Println("\t\t\t--order defaults to bc; -n N shows N episodes.")
+	fmt.Println("\tprev\tShow the episode before another, as for next.")
+	fmt.Println("\tconvert\tConvert numbers between orderings, e.g. \"convert --from viz --to bc 1-20,35\"")
+	fmt.Println("\t\t\tOrderings are nh, bc, viz and prod; --to defaults to all of them.")
+	fmt.Println("\tepisodes\t List episodes (as tab-separated data by default). Filters, which")
+	fmt.Println("\t\t\tcombine: --series original|nettohen, --nh/--bc/--viz/--prod RANGE,")
+	fmt.Println("\t\t\t--aired-after DATE, --aired-before DATE, --title-contains TEXT and")
+	fmt.Println("\t\t\t--reordered. --sort nh|bc|viz|prod|date|name and --reverse order them.")
+	fmt.Println("\twatched\tMark episodes watched, e.g. \"watched bc:1-20 nh:5 viz:40\". Bare numbers")
+	fmt.Println("\t\t\tare broadcast numbers.")
+	fmt.Println("\tunwatched\tMark episodes not watched.")
+	fmt.Println("\tprogress\tShow how much of each series has been watched.")
+	fmt.Println("\tup-next\tShow the first unwatched episode in an ordering (--order, default bc);")
+	fmt.Println("\t\t\t-n N shows N episodes.")
+	fmt.Println("\thistory\tShow what was watched when; -n N shows the last N.")
+	fmt.Println("\tprofile\tManage watch profiles: \"profile list\", \"profile compare [NAME]...\",")
+	fmt.Println("\t\t\t\"profile export [NAME] [-o FILE]\" and \"profile import FILE [--as NAME]\".")
+	fmt.Println("\tscan\tIdentify the episodes in a directory of video files by their names,")
+	fmt.Println("\t\t\tflagging ambiguous and unmatched files; -r scans subdirectories.")
+	fmt.Println("\t\t\t--order bc|viz|nh says what bare numbers are; by default it's guessed")
+	fmt.Println("\t\t\tfor each directory from the names that give an ordering or a title.")
+	fmt.Println("\trename\tRename the video files in a directory, and their .srt/.ass subtitles,")
+	fmt.Println("\t\t\tto --pattern (default \"{bc:03} - {name}\"; placeholders are nh, bc,")
+	fmt.Println("\t\t\tviz, prod, name, rjname, jpname, date, season and episode). Only shows")
+	fmt.Println("\t\t\tthe new names unless --apply is given; --undo reverts the last rename.")
+	fmt.Println("\t\t\t--order bc|viz|nh says what bare numbers are, as for scan.")
+	fmt.Println("\tplaylist\tMake an M3U or XSPF playlist of the video files in a directory, e.g.")
+	fmt.Println("\t\t\t\"playlist --order viz --from 20 --to 40 -o out.m3u DIR\". --unwatched")
+	fmt.Println("\t\t\tleaves out watched episodes, -n N stops after N, and --type m3u|xspf")
+	fmt.Println("\t\t\tsets the type if the -o extension doesn't.")
+	fmt.Println("\texport nfo\tWrite Kodi/Jellyfin NFO files for the video files in a directory:")
+	fmt.Println("\t\t\ttvshow.nfo, and one named after each video. --order bc|viz|seasons")
+	fmt.Println("\t\t\tpicks the numbering (seasons makes Nettohen season 2); --force")
+	fmt.Println("\t\t\treplaces existing files.")
+	fmt.Println("\texport ics\tWrite an iCalendar file with an all-day event on each episode's air")
+	fmt.Println("\t\t\tdate (-o FILE, default standard output); --anniversary makes them")
+	fmt.Println("\t\t\trecur every year.")
+	fmt.Println("\tdata diff\tShow what the overlay file changes")
+	fmt.Println("\thelp\t(Alias \"usage\") Display this message.")
+	fmt.Println("\nOptions are:")
+	fmt.Println("\t--data FILE\tOverlay FILE over the built-in episode data.")
+	fmt.Println("\t\t\tDefaults to $XDG_CONFIG_HOME/ranma/episodes.json if it exists.")
+	fmt.Println("\t--format FORMAT\tPrint episodes as text (the default), json, jsonl, tsv, csv, yaml")
+	fmt.Println("\t\t\tor markdown.")
+	fmt.Println("\t--columns LIST\tComma-separated columns for tsv, csv, yaml and markdown: nh, bc,")
+	fmt.Println("\t\t\tviz, prod, name, rjname, jpname, date and notes.")
+	fmt.Println("\t--template TEXT\tPrint each episode with a Go text/template.")
+	fmt.Println("\t--template-file FILE\tRead the --template from FILE.")
+	fmt.Println("\t--state FILE\tKeep watch progress in FILE.")
+	fmt.Println("\t\t\tDefaults to $XDG_DATA_HOME/ranma/watched.json.")
+	fmt.Println("\t--profile NAME\tWhose watch progress to use (default \"default\").")
+}
+
+func main() {
+	global := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	global.Usage = usage
+	addGlobalFlags(global)
+	if err := global.Parse(os.Args[1:]); err != nil {
+		os.Exit(-1)
+	}
+
+	if global.NArg() < 1 {
+		usage()
+		os.Exit(-1)
+	}
+
+	cmd, args := global.Arg(0), global.Args()[1:]
+	switch cmd {
+	case "help", "usage":
+		usage()
+	case "rjname":
+		cmdRomajiName(args)
+	case "jpname":
+		cmdJapaneseName(args)
+	case "name":
+		cmdName(args)
+	case "prod", "production":
+		cmdProduction(args)
+	case "viz":
+		cmdViz(args)
+	case "bc", "broadcast":
+		cmdBroadcast(args)
+	case "episodes":
+		cmdEpisodes(args)
+	case "nh", "nettohen":
+		cmdNettohen(args)
+	case "grep":
+		cmdGrep(args)
+	case "query":
+		cmdQuery(args)
+	case "date":
+		cmdDate(args)
+	case "aired":
+		cmdAired(args)
+	case "year":
+		cmdYear(args)
+	case "today":
+		cmdToday(args)
+	case "order":
+		cmdOrder(args)
+	case "next":
+		cmdNext(args)
+	case "prev":
+		cmdPrev(args)
+	case "convert":
+		cmdConvert(args)
+	case "watched":
+		cmdWatched(args)
+	case "unwatched":
+		cmdUnwatched(args)
+	case "progress":
+		cmdProgress(args)
+	case "up-next":
+		cmdUpNext(args)
+	case "history":
+		cmdHistory(args)
+	case "profile":
+		cmdProfile(args)
+	case "scan":
+		cmdScan(args)
+	case "rename":
+		cmdRename(args)
+	case "playlist":
+		cmdPlaylist(args)
+	case "export":
+		cmdExport(args)
+	case "data":
+		cmdData(args)
+	default:
+		fmt.Printf("Unknown command %s\n", cmd)
+		os.Exit(-1)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/japanoise/ranma"
+)
+
+func cmdNext(args []string) {
+	navigate("next", "after", (*ranma.Table).Next, args)
+}
+
+func cmdPrev(args []string) {
+	navigate("prev", "before", (*ranma.Table).Prev, args)
+}
+
+// navigate prints the episodes next to another in a chosen ordering.
+func navigate(cmd, where string, step func(*ranma.Table, ranma.Order, int, int) ([]ranma.Episode, error), args []string) {
+	fs := newFlags(cmd)
+	orderName := fs.String("order", "bc", "ordering to step through: nh, bc, viz or prod")
+	count := fs.Int("n", 1, "number of episodes to list")
+	args = parseFlags(fs, args)
+	requiresArgs(args, 1)
+	if *count < 1 {
+		fmt.Println("Bad argument: -n must be at least 1")
+		os.Exit(-1)
+	}
+	table := loadTable()
+
+	order := parseOrderArg(*orderName)
+	arg, err := strconv.Atoi(args[0])
+	if err != nil {
+		fmt.Printf("Bad argument: %v\n", err)
+		os.Exit(-1)
+	}
+
+	list, err := step(table, order, arg, *count)
+	if err != nil {
+		fmt.Printf("Can't find %s episode %d\n", order, arg)
+		explainRange(table, order)
+		os.Exit(-1)
+	}
+	if len(list) == 0 {
+		fmt.Printf("There are no %s episodes %s %d\n", order, where, arg)
+		os.Exit(-1)
+	}
+
+	show(list, *count == 1)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/japanoise/ranma"
+)
+
+// nfoNumberings are the ways export nfo can number episodes for a media
+// server, as a season and episode number.
+var nfoNumberings = map[string]func(ranma.Episode) (int, int){
+	"bc":      func(e ranma.Episode) (int, int) { return 1, e.Broadcast() },
+	"viz":     func(e ranma.Episode) (int, int) { return 1, e.Viz() },
+	"seasons": ranma.Episode.SeasonEpisode,
+}
+
+// nfoShow is a Kodi tvshow.nfo file, which Jellyfin reads too.
+type nfoShow struct {
+	XMLName       xml.Name         `xml:"tvshow"`
+	Title         string           `xml:"title"`
+	OriginalTitle string           `xml:"originaltitle"`
+	Premiered     string           `xml:"premiered"`
+	Seasons       []nfoNamedSeason `xml:"namedseason"`
+}
+
+type nfoNamedSeason struct {
+	Number int    `xml:"number,attr"`
+	Name   string `xml:",chardata"`
+}
+
+// nfoEpisode is a Kodi episode NFO file, named after the video it
+// describes.
+type nfoEpisode struct {
+	XMLName       xml.Name `xml:"episodedetails"`
+	Title         string   `xml:"title"`
+	OriginalTitle string   `xml:"originaltitle,omitempty"`
+	ShowTitle     string   `xml:"showtitle"`
+	Season        int      `xml:"season"`
+	Episode       int      `xml:"episode"`
+	Aired         string   `xml:"aired"`
+	Plot          string   `xml:"plot,omitempty"`
+}
+
+// exportNFO writes tvshow.nfo to dir, and an NFO file for each video in it
+// that can be identified, numbered as numbering says.
+func exportNFO(dir, numbering string, recursive, force bool) {
+	number, ok := nfoNumberings[numbering]
+	if !ok {
+		fmt.Printf("Unknown numbering %s (want bc, viz or seasons)\n", numbering)
+		os.Exit(-1)
+	}
+	table := loadTable()
+
+	show := nfoShow{Title: "Ranma ½", OriginalTitle: "らんま½"}
+	for _, epi := range table.Episodes() {
+		if show.Premiered == "" || ranma.JPDate(epi.Date()) < show.Premiered {
+			show.Premiered = ranma.JPDate(epi.Date())
+		}
+	}
+	if numbering == "seasons" {
+		show.Seasons = []nfoNamedSeason{{1, "Ranma ½"}, {2, "Ranma ½ Nettohen"}}
+	}
+
+	written, skipped := 0, 0
+	write := func(path string, v interface{}) {
+		if !force {
+			if _, err := os.Stat(path); err == nil {
+				fmt.Printf("Skipping %s: it already exists (use --force to replace it)\n", path)
+				skipped++
+				return
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				fmt.Println(err)
+				os.Exit(-1)
+			}
+		}
+		b, err := xml.MarshalIndent(v, "", "  ")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		if err := os.WriteFile(path, append([]byte(xml.Header), append(b, '\n')...), 0o644); err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		written++
+	}
+
+	// Bare numbers in the file names are taken to be in the numbering
+	// asked for; seasons doesn't say, so that's left to scanDir to guess.
+	var hint *ranma.Order
+	if numbering != "seasons" {
+		hint = parseHintArg(numbering)
+	}
+
+	write(filepath.Join(dir, "tvshow.nfo"), show)
+	for _, r := range scanDir(table, dir, recursive, hint) {
+		switch {
+		case r.id.Episode == nil:
+			fmt.Printf("Skipping %s: %s\n", r.path, r.id.Reason)
+			skipped++
+			continue
+		case r.id.Ambiguous():
+			fmt.Printf("Skipping %s: ambiguous (%s)\n", r.path, r.id.Reason)
+			skipped++
+			continue
+		}
+
+		epi := *r.id.Episode
+		season, episode := number(epi)
+		write(strings.TrimSuffix(r.path, filepath.Ext(r.path))+".nfo", nfoEpisode{
+			Title:         epi.Name(),
+			OriginalTitle: epi.JapaneseName(),
+			ShowTitle:     show.Title,
+			Season:        season,
+			Episode:       episode,
+			Aired:         ranma.JPDate(epi.Date()),
+			Plot:          epi.Notes(),
+		})
+	}
+
+	fmt.Printf("Wrote %d NFO files", written)
+	if skipped > 0 {
+		fmt.Printf(", skipped %d", skipped)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportNFOViz(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+
+	// Without a title these are ambiguous unless read as Viz numbers.
+	want := map[string]struct {
+		episode int
+		title   string
+	}{
+		"[Grp] Ranma 1-2 - 010.mkv": {10, "P-P-P-Chan! He's Good For Nothin'"},
+		"[Grp] Ranma 1-2 - 019.mkv": {19, "The Abduction of P-Chan"},
+		"[Grp] Ranma 1-2 - 022.mkv": {22, "Clash of the Delivery Girls! The Martial Arts Takeout Race"},
+	}
+	for name := range want {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exportNFO(dir, "viz", false, false)
+
+	for name, w := range want {
+		path := filepath.Join(dir, strings.TrimSuffix(name, ".mkv")+".nfo")
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		var epi nfoEpisode
+		if err := xml.Unmarshal(b, &epi); err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if epi.Title != w.title || epi.Season != 1 || epi.Episode != w.episode {
+			t.Errorf("%s: got S%02dE%02d %q; want S01E%02d %q", path, epi.Season, epi.Episode, epi.Title, w.episode, w.title)
+		}
+	}
+}
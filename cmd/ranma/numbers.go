@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// parseNumbers parses a comma-separated list of numbers and ranges such as
+// "1,5,9-12". A range with no end, like "100-", runs up to last, and no
+// range may go past it.
+func parseNumbers(spec string, last int) ([]int, error) {
+	var ret []int
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		dash := strings.Index(part, "-")
+		if dash < 0 {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("bad number %q", part)
+			}
+			ret = append(ret, n)
+			continue
+		}
+
+		from, err := strconv.Atoi(part[:dash])
+		if err != nil {
+			return nil, fmt.Errorf("bad range %q", part)
+		}
+		to := last
+		if part[dash+1:] != "" {
+			to, err = strconv.Atoi(part[dash+1:])
+			if err != nil {
+				return nil, fmt.Errorf("bad range %q", part)
+			}
+		}
+		if from > last || to > last {
+			return nil, fmt.Errorf("range %q goes past the last episode, %d", part, last)
+		}
+		if to < from {
+			return nil, fmt.Errorf("backwards range %q", part)
+		}
+		for n := from; n <= to; n++ {
+			ret = append(ret, n)
+		}
+	}
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("no numbers in %q", spec)
+	}
+	return ret, nil
+}
+
+// formatNumbers is the inverse of parseNumbers, collapsing runs of
+// consecutive numbers into ranges, e.g. "1-18,20".
+func formatNumbers(nums []int) string {
+	sorted := append([]int(nil), nums...)
+	sort.Ints(sorted)
+
+	var parts []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] == sorted[j]+1 {
+			j++
+		}
+		if j > i {
+			parts = append(parts, fmt.Sprintf("%d-%d", sorted[i], sorted[j]))
+		} else {
+			parts = append(parts, strconv.Itoa(sorted[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/japanoise/ranma"
+)
+
+func parseOrderArg(s string) ranma.Order {
+	o, err := ranma.ParseOrder(s)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+	return o
+}
+
+func cmdOrder(args []string) {
+	fs := newFlags("order")
+	playlist := fs.Bool("playlist", false, "print one episode per line: its number and title")
+	args = parseFlags(fs, args)
+	requiresArgs(args, 1)
+	table := loadTable()
+
+	if args[0] == "diff" {
+		requiresArgs(args, 3)
+		orderDiff(table, parseOrderArg(args[1]), parseOrderArg(args[2]))
+		return
+	}
+
+	order := parseOrderArg(args[0])
+	list := table.Sorted(order)
+
+	if *playlist {
+		for _, epi := range list {
+			fmt.Printf("%d\t%s\n", order.Number(epi), epi.Name())
+		}
+		return
+	}
+
+	if opts.format == "" && opts.template == "" && opts.templateFile == "" {
+		opts.format = "tsv"
+		if opts.columns == "" {
+			// The chosen ordering first, then the others.
+			cols := []string{order.Short()}
+			for _, o := range ranma.Orders {
+				if o != order {
+					cols = append(cols, o.Short())
+				}
+			}
+			opts.columns = strings.Join(append(cols, "name"), ",")
+		}
+	}
+	show(list, false)
+}
+
+func orderDiff(table *ranma.Table, a, b ranma.Order) {
+	shifts := table.Compare(a, b)
+	if len(shifts) == 0 {
+		fmt.Printf("%s and %s orders agree\n", a, b)
+		return
+	}
+
+	fmt.Printf("%s\t%s\tshift\tEN Title\n", a.Short(), b.Short())
+	for _, s := range shifts {
+		fmt.Printf("%d\t%d\t%+d\t%s\n", a.Number(s.Episode), b.Number(s.Episode), s.To-s.From, s.Episode.Name())
+	}
+}
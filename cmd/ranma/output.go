@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/japanoise/ranma"
+)
+
+type column struct {
+	name   string // used with --columns
+	key    string // used as the key in structured formats
+	header string // used as the header in tabular formats
+	value  func(ranma.Episode) string
+}
+
+var allColumns = []column{
+	{"nh", "nettohen", "Nettohen No.", func(e ranma.Episode) string { return strconv.Itoa(e.Nettohen()) }},
+	{"bc", "broadcast", "Broadcast No.", func(e ranma.Episode) string { return strconv.Itoa(e.Broadcast()) }},
+	{"viz", "viz", "Viz No.", func(e ranma.Episode) string { return strconv.Itoa(e.Viz()) }},
+	{"prod", "production", "Production No.", func(e ranma.Episode) string { return strconv.Itoa(e.Production()) }},
+	{"name", "name", "EN Title", ranma.Episode.Name},
+	{"rjname", "rjname", "JP Title (romaji)", ranma.Episode.RomajiName},
+	{"jpname", "jpname", "JP Title", ranma.Episode.JapaneseName},
+	{"date", "date", "Broadcast Date (YYYY-MM-DD)", func(e ranma.Episode) string { return ranma.JPDate(e.Date()) }},
+	{"notes", "notes", "Notes", ranma.Episode.Notes},
+}
+
+// defaultColumns are the columns of the original tab-separated listing.
+var defaultColumns = allColumns[:8]
+
+// selectedColumns parses --columns, e.g. "bc,name,date". The long names
+// used as JSON keys are accepted too.
+func selectedColumns() []column {
+	if opts.columns == "" {
+		return defaultColumns
+	}
+
+	var ret []column
+	for _, name := range strings.Split(opts.columns, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, col := range allColumns {
+			if name == col.name || name == col.key {
+				ret = append(ret, col)
+				found = true
+				break
+			}
+		}
+		if !found {
+			var names []string
+			for _, col := range allColumns {
+				names = append(names, col.name)
+			}
+			fmt.Printf("Unknown column %s (columns are %s)\n", name, strings.Join(names, ", "))
+			os.Exit(-1)
+		}
+	}
+	return ret
+}
+
+// show prints episodes with the template given with --template or
+// --template-file, or else in the format chosen with --format. Results of
+// single-episode lookups are printed as a single object rather than a list
+// where the format makes a difference.
+func show(list []ranma.Episode, single bool) {
+	if tmpl := loadTemplate(); tmpl != nil {
+		writeTemplate(list, tmpl)
+		return
+	}
+
+	switch opts.format {
+	case "", "text":
+		for i, epi := range list {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Println(epi)
+		}
+	case "json":
+		// An empty list is [] rather than null, so the schema doesn't change.
+		var v interface{} = append([]ranma.Episode{}, list...)
+		if single && len(list) == 1 {
+			v = list[0]
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(v); err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+	case "jsonl":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		for _, epi := range list {
+			if err := enc.Encode(epi); err != nil {
+				fmt.Println(err)
+				os.Exit(-1)
+			}
+		}
+	case "tsv":
+		writeTSV(list, selectedColumns())
+	case "csv":
+		writeCSV(list, selectedColumns())
+	case "markdown", "md":
+		writeMarkdown(list, selectedColumns())
+	case "yaml":
+		writeYAML(list, selectedColumns(), single)
+	default:
+		fmt.Printf("Unknown format %s\n", opts.format)
+		os.Exit(-1)
+	}
+}
+
+// tsvEscaper escapes the characters that would otherwise break a row.
+var tsvEscaper = strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r")
+
+func writeTSV(list []ranma.Episode, cols []column) {
+	row := make([]string, len(cols))
+	for i, col := range cols {
+		row[i] = col.header
+	}
+	fmt.Println(strings.Join(row, "\t"))
+
+	for _, epi := range list {
+		for i, col := range cols {
+			row[i] = tsvEscaper.Replace(col.value(epi))
+		}
+		fmt.Println(strings.Join(row, "\t"))
+	}
+}
+
+func writeCSV(list []ranma.Episode, cols []column) {
+	w := csv.NewWriter(os.Stdout)
+	row := make([]string, len(cols))
+	for i, col := range cols {
+		row[i] = col.header
+	}
+	w.Write(row)
+
+	for _, epi := range list {
+		for i, col := range cols {
+			row[i] = col.value(epi)
+		}
+		w.Write(row)
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+}
+
+var markdownEscaper = strings.NewReplacer("\\", "\\\\", "|", "\\|", "\n", "<br>", "\r", "")
+
+func writeMarkdown(list []ranma.Episode, cols []column) {
+	row := make([]string, len(cols))
+	for i, col := range cols {
+		row[i] = col.header
+	}
+	fmt.Printf("| %s |\n", strings.Join(row, " | "))
+	for i := range cols {
+		row[i] = "---"
+	}
+	fmt.Printf("| %s |\n", strings.Join(row, " | "))
+
+	for _, epi := range list {
+		for i, col := range cols {
+			row[i] = markdownEscaper.Replace(col.value(epi))
+		}
+		fmt.Printf("| %s |\n", strings.Join(row, " | "))
+	}
+}
+
+// writeYAML writes a sequence of mappings, or a single mapping for
+// single-episode lookups. Strings are double-quoted with JSON escaping,
+// which is valid YAML.
+func writeYAML(list []ranma.Episode, cols []column, single bool) {
+	indent := "  "
+	if single && len(list) == 1 {
+		indent = ""
+	} else if len(list) == 0 {
+		fmt.Println("[]")
+		return
+	}
+
+	for _, epi := range list {
+		for i, col := range cols {
+			prefix := indent
+			if i == 0 && indent != "" {
+				prefix = "- "
+			}
+			fmt.Printf("%s%s: %s\n", prefix, col.key, yamlValue(epi, col))
+		}
+	}
+}
+
+func yamlValue(epi ranma.Episode, col column) string {
+	switch col.key {
+	case "nettohen":
+		if !epi.IsNettohen() {
+			return "null"
+		}
+		fallthrough
+	case "broadcast", "viz", "production":
+		return col.value(epi)
+	}
+	return jsonString(col.value(epi))
+}
+
+func jsonString(s string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/japanoise/ranma"
+)
+
+// playlistEntry is a video file to play and the episode it is.
+type playlistEntry struct {
+	path    string
+	episode ranma.Episode
+}
+
+func cmdPlaylist(args []string) {
+	fs := newFlags("playlist")
+	orderName := fs.String("order", "bc", "ordering to play in: nh, bc, viz or prod")
+	from := fs.Int("from", 0, "first episode number to include")
+	to := fs.Int("to", 0, "last episode number to include")
+	count := fs.Int("n", 0, "include at most N episodes")
+	unwatched := fs.Bool("unwatched", false, "leave out episodes marked watched")
+	output := fs.String("o", "", "file to write to (default standard output)")
+	kind := fs.String("type", "", "playlist type: m3u or xspf (default from the -o extension, else m3u)")
+	recursive := fs.Bool("r", false, "look in subdirectories too")
+	args = parseFlags(fs, args)
+	requiresArgs(args, 1)
+
+	if *kind == "" {
+		*kind = "m3u"
+		if strings.EqualFold(filepath.Ext(*output), ".xspf") {
+			*kind = "xspf"
+		}
+	}
+	var write func(io.Writer, string, []playlistEntry) error
+	switch *kind {
+	case "m3u", "m3u8":
+		write = writeM3U
+	case "xspf":
+		write = writeXSPF
+	default:
+		fmt.Printf("Unknown playlist type %s (want m3u or xspf)\n", *kind)
+		os.Exit(-1)
+	}
+
+	table := loadTable()
+	order := parseOrderArg(*orderName)
+
+	// Bare numbers in the file names are taken to be in the order asked
+	// for, or guessed for each directory if there was no --order.
+	var hint *ranma.Order
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "order" {
+			hint = &order
+		}
+	})
+
+	// The first file found for each episode is the one that's played.
+	files := make(map[int]string)
+	for _, dir := range args {
+		for _, r := range scanDir(table, dir, *recursive, hint) {
+			switch {
+			case r.id.Episode == nil:
+				fmt.Fprintf(os.Stderr, "Skipping %s: %s\n", r.path, r.id.Reason)
+				continue
+			case r.id.Ambiguous():
+				fmt.Fprintf(os.Stderr, "Skipping %s: ambiguous (%s)\n", r.path, r.id.Reason)
+				continue
+			}
+			if _, ok := files[r.id.Episode.Production()]; !ok {
+				files[r.id.Episode.Production()] = r.path
+			}
+		}
+	}
+
+	var profile *watchProfile
+	if *unwatched {
+		profile = loadState().profile()
+	}
+
+	var list []playlistEntry
+	var missing []int
+	for _, epi := range table.Sorted(order) {
+		n := order.Number(epi)
+		if n < *from || *to > 0 && n > *to {
+			continue
+		}
+		if *count > 0 && len(list) >= *count {
+			break
+		}
+		if profile != nil && profile.isWatched(epi) {
+			continue
+		}
+		path, ok := files[epi.Production()]
+		if !ok {
+			missing = append(missing, n)
+			continue
+		}
+		list = append(list, playlistEntry{path: path, episode: epi})
+	}
+	if len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "No files for %s %s\n", order, formatNumbers(missing))
+	}
+	if len(list) == 0 {
+		fmt.Println("No episodes to play")
+		os.Exit(-1)
+	}
+
+	var w io.Writer = os.Stdout
+	base := ""
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		defer f.Close()
+		w = f
+		base = filepath.Dir(*output)
+	}
+	bw := bufio.NewWriter(w)
+	err := write(bw, base, list)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+	if *output != "" {
+		fmt.Printf("Wrote %d episodes to %s\n", len(list), *output)
+	}
+}
+
+// writeM3U writes an extended M3U playlist, in UTF-8. Paths are relative to
+// base if it is set, so that the playlist can sit alongside the files.
+func writeM3U(w io.Writer, base string, list []playlistEntry) error {
+	fmt.Fprintln(w, "#EXTM3U")
+	for _, e := range list {
+		path, err := playlistPath(base, e.path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "#EXTINF:-1,%s\n%s\n", e.episode.Name(), path)
+	}
+	return nil
+}
+
+func playlistPath(base, path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil || base == "" {
+		return abs, err
+	}
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(absBase, abs); err == nil {
+		return rel, nil
+	}
+	return abs, nil
+}
+
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"playlist"`
+	Version string      `xml:"version,attr"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location"`
+	Title    string `xml:"title"`
+	Album    string `xml:"album"`
+	TrackNum int    `xml:"trackNum"`
+}
+
+// writeXSPF writes an XSPF playlist. Locations are file URLs relative to
+// base if it is set.
+func writeXSPF(w io.Writer, base string, list []playlistEntry) error {
+	pl := xspfPlaylist{Version: "1", XMLNS: "http://xspf.org/ns/0/", Title: "Ranma ½"}
+	for i, e := range list {
+		path, err := playlistPath(base, e.path)
+		if err != nil {
+			return err
+		}
+		loc := &url.URL{Path: filepath.ToSlash(path)}
+		if filepath.IsAbs(path) {
+			loc.Scheme = "file"
+		}
+		pl.Tracks = append(pl.Tracks, xspfTrack{
+			Location: loc.String(),
+			Title:    e.episode.Name(),
+			Album:    pl.Title,
+			TrackNum: i + 1,
+		})
+	}
+
+	b, err := xml.MarshalIndent(pl, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, b)
+	return err
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/japanoise/ranma"
+)
+
+// profileExport is the layout of a single exported profile.
+type profileExport struct {
+	Version int               `json:"version"`
+	Profile string            `json:"profile"`
+	Watched map[int]time.Time `json:"watched"`
+	History []watchEvent      `json:"history"`
+}
+
+func cmdProfile(args []string) {
+	fs := newFlags("profile")
+	orderName := fs.String("order", "bc", "ordering to measure progress in when comparing")
+	output := fs.String("o", "", "file to export to (default standard output)")
+	as := fs.String("as", "", "name to import the profile as (default the name in the file)")
+	replace := fs.Bool("replace", false, "let import replace an existing profile")
+	args = parseFlags(fs, args)
+	requiresArgs(args, 1)
+
+	switch args[0] {
+	case "list":
+		state := loadState()
+		for _, name := range state.names() {
+			fmt.Printf("%s\t%d watched\n", name, len(state.Profiles[name].Watched))
+		}
+	case "compare":
+		compareProfiles(parseOrderArg(*orderName), args[1:])
+	case "export":
+		name := profileName()
+		if len(args) > 1 {
+			name = args[1]
+		}
+		exportProfile(name, *output)
+	case "import":
+		requiresArgs(args, 2)
+		importProfile(args[1], *as, *replace)
+	default:
+		fmt.Printf("Unknown profile command %s\n", args[0])
+		os.Exit(-1)
+	}
+}
+
+// compareProfiles shows how far each profile has got, and what they've all
+// seen. With no names, every profile is compared.
+func compareProfiles(order ranma.Order, names []string) {
+	table := loadTable()
+	state := loadState()
+	if len(names) == 0 {
+		names = state.names()
+	}
+	if len(names) == 0 {
+		fmt.Println("There are no profiles yet")
+		os.Exit(-1)
+	}
+
+	total := len(table.Episodes())
+	ahead, aheadCount := "", -1
+	fmt.Printf("Profile\tWatched\tDone\tFurthest (%s)\n", order.Short())
+	for _, name := range names {
+		p, ok := state.Profiles[name]
+		if !ok {
+			fmt.Printf("No such profile %s\n", name)
+			os.Exit(-1)
+		}
+
+		furthest := "-"
+		list := table.Sorted(order)
+		for i := len(list) - 1; i >= 0; i-- {
+			if p.isWatched(list[i]) {
+				furthest = fmt.Sprint(order.Number(list[i]))
+				break
+			}
+		}
+
+		fmt.Printf("%s\t%d/%d\t%.1f%%\t%s\n", name, len(p.Watched), total, percent(len(p.Watched), total), furthest)
+		if len(p.Watched) > aheadCount {
+			ahead, aheadCount = name, len(p.Watched)
+		}
+	}
+
+	if len(names) < 2 {
+		return
+	}
+	fmt.Printf("\nFurthest ahead: %s\n", ahead)
+
+	var common []int
+	for _, epi := range table.Sorted(order) {
+		everyone := true
+		for _, name := range names {
+			if !state.Profiles[name].isWatched(epi) {
+				everyone = false
+				break
+			}
+		}
+		if everyone {
+			common = append(common, order.Number(epi))
+		}
+	}
+	if len(common) == 0 {
+		fmt.Println("No episode has been seen by everyone")
+	} else {
+		fmt.Printf("Seen by everyone: %d episodes (%s %s)\n", len(common), order.Short(), formatNumbers(common))
+	}
+}
+
+func exportProfile(name, output string) {
+	state := loadState()
+	p, ok := state.Profiles[name]
+	if !ok {
+		fmt.Printf("No such profile %s\n", name)
+		os.Exit(-1)
+	}
+
+	b, err := json.MarshalIndent(profileExport{
+		Version: watchStateVersion,
+		Profile: name,
+		Watched: p.Watched,
+		History: p.History,
+	}, "", "\t")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+	b = append(b, '\n')
+
+	if output == "" {
+		os.Stdout.Write(b)
+		return
+	}
+	if err := os.WriteFile(output, b, 0o644); err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+}
+
+// importProfile reads a profile written by exportProfile; "-" reads
+// standard input.
+func importProfile(path, as string, replace bool) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var in profileExport
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		fmt.Printf("%s: %v\n", path, err)
+		os.Exit(-1)
+	}
+	if in.Version != watchStateVersion {
+		fmt.Printf("%s: unsupported profile version %d (want %d)\n", path, in.Version, watchStateVersion)
+		os.Exit(-1)
+	}
+
+	name := in.Profile
+	if as != "" {
+		name = as
+	}
+	if name == "" {
+		fmt.Printf("%s doesn't name its profile; use --as NAME\n", path)
+		os.Exit(-1)
+	}
+
+	state := loadState()
+	if _, exists := state.Profiles[name]; exists && !replace {
+		fmt.Printf("Profile %s already exists; use --replace to overwrite it\n", name)
+		os.Exit(-1)
+	}
+	if in.Watched == nil {
+		in.Watched = make(map[int]time.Time)
+	}
+	state.Profiles[name] = &watchProfile{Watched: in.Watched, History: in.History}
+	state.save()
+
+	fmt.Printf("Imported profile %s (%d watched)\n", name, len(in.Watched))
+}
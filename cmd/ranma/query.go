@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/japanoise/ranma"
+)
+
+func cmdQuery(args []string) {
+	args = parseFlags(newFlags("query"), args)
+	requiresArgs(args, 1)
+	table := loadTable()
+
+	src := strings.Join(args, " ")
+	query, err := ranma.ParseQuery(src)
+	if err != nil {
+		var qerr *ranma.QueryError
+		if errors.As(err, &qerr) {
+			fmt.Printf("Bad query: %s\n", qerr.Msg)
+			fmt.Printf("\t%s\n\t%s^\n", src, strings.Repeat(" ", qerr.Column-1))
+		} else {
+			fmt.Printf("Bad query: %v\n", err)
+		}
+		os.Exit(-1)
+	}
+
+	list := table.Filter(query.Match)
+	if len(list) == 0 {
+		fmt.Println("No episodes match")
+		os.Exit(-1)
+	}
+	show(list, false)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/japanoise/ranma"
+)
+
+// parseRefs resolves episode references such as "bc:1-20", "nh:5",
+// "viz:40,41" or "prod:22". A reference without an ordering is a broadcast
+// number.
+func parseRefs(table *ranma.Table, args []string) ([]ranma.Episode, error) {
+	var ret []ranma.Episode
+	for _, arg := range args {
+		order, spec := ranma.OrderBroadcast, arg
+		if i := strings.Index(arg, ":"); i >= 0 {
+			var err error
+			order, err = ranma.ParseOrder(arg[:i])
+			if err != nil {
+				return nil, err
+			}
+			spec = arg[i+1:]
+		}
+
+		_, last := table.Range(order)
+		nums, err := parseNumbers(spec, last)
+		if err != nil {
+			return nil, err
+		}
+		found, missing := findNumbers(table, order, nums)
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("can't find %s episode %d", order, missing[0])
+		}
+		ret = append(ret, found...)
+	}
+	return ret, nil
+}
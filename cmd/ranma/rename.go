@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/japanoise/ranma"
+)
+
+// renameLog is the undo log rename keeps in the directory it renamed in.
+const renameLog = ".ranma-rename.log"
+
+// subtitleExtensions are the sidecar files renamed along with a video.
+var subtitleExtensions = map[string]bool{".srt": true, ".ass": true, ".ssa": true}
+
+// placeholders are the values a rename pattern can use. A nil value means
+// the episode doesn't have one.
+var placeholders = map[string]func(ranma.Episode) interface{}{
+	"nh": func(e ranma.Episode) interface{} {
+		if !e.IsNettohen() {
+			return nil
+		}
+		return e.Nettohen()
+	},
+	"bc":     func(e ranma.Episode) interface{} { return e.Broadcast() },
+	"viz":    func(e ranma.Episode) interface{} { return e.Viz() },
+	"prod":   func(e ranma.Episode) interface{} { return e.Production() },
+	"name":   func(e ranma.Episode) interface{} { return e.Name() },
+	"rjname": func(e ranma.Episode) interface{} { return e.RomajiName() },
+	"jpname": func(e ranma.Episode) interface{} { return e.JapaneseName() },
+	"date":   func(e ranma.Episode) interface{} { return ranma.JPDate(e.Date()) },
+	"season": func(e ranma.Episode) interface{} {
+		s, _ := e.SeasonEpisode()
+		return s
+	},
+	"episode": func(e ranma.Episode) interface{} {
+		_, n := e.SeasonEpisode()
+		return n
+	},
+}
+
+var placeholderPattern = regexp.MustCompile(`\{(\w+)(?::(\d+))?\}`)
+
+// checkPattern makes sure every placeholder in a rename pattern exists.
+func checkPattern(pattern string) error {
+	if strings.ContainsAny(pattern, `/\`) {
+		return errors.New("the pattern can't contain a directory")
+	}
+	for _, m := range placeholderPattern.FindAllStringSubmatch(pattern, -1) {
+		if _, ok := placeholders[m[1]]; !ok {
+			return fmt.Errorf("unknown placeholder %s (placeholders are nh, bc, viz, prod, name, rjname, jpname, date, season and episode)", m[0])
+		}
+	}
+	return nil
+}
+
+// expandPattern fills in a rename pattern for an episode. {bc:03} pads the
+// broadcast number with zeroes to three digits; a width on anything but a
+// number is ignored. It fails if the episode lacks a value the pattern
+// uses, such as {nh} for the original series.
+func expandPattern(pattern string, epi ranma.Episode) (string, error) {
+	var err error
+	name := placeholderPattern.ReplaceAllStringFunc(pattern, func(s string) string {
+		m := placeholderPattern.FindStringSubmatch(s)
+		v := placeholders[m[1]](epi)
+		if v == nil {
+			err = fmt.Errorf("it has no %s value", m[0])
+			return ""
+		}
+		if n, ok := v.(int); ok && m[2] != "" {
+			width, _ := strconv.Atoi(m[2])
+			return fmt.Sprintf("%0*d", width, n)
+		}
+		return sanitize(fmt.Sprint(v))
+	})
+	return strings.TrimRight(strings.TrimSpace(name), ". "), err
+}
+
+var unsafeChars = strings.NewReplacer(
+	":", " -",
+	"/", "-", `\`, "-", "|", "-",
+	"*", "", "?", "", `"`, "", "<", "", ">", "",
+)
+
+// sanitize makes a title safe to use in a file name on any filesystem.
+func sanitize(s string) string {
+	s = unsafeChars.Replace(s)
+	s = strings.Map(func(r rune) rune {
+		if r < ' ' {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// rename is a file to be renamed, with paths relative to the directory
+// being renamed in.
+type rename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// renameBatch is one run of rename --apply, as kept in the undo log.
+type renameBatch struct {
+	Time    time.Time `json:"time"`
+	Renames []rename  `json:"renames"`
+}
+
+// sidecars returns the subtitle files that go with a video: those named
+// after it, like "ep.srt" or "ep.en.ass" for "ep.mkv".
+func sidecars(video string) []string {
+	stem := strings.TrimSuffix(video, filepath.Ext(video))
+	matches, _ := filepath.Glob(escapeGlob(stem) + ".*")
+	var ret []string
+	for _, m := range matches {
+		if subtitleExtensions[strings.ToLower(filepath.Ext(m))] {
+			ret = append(ret, m)
+		}
+	}
+	return ret
+}
+
+func escapeGlob(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`).Replace(s)
+}
+
+func cmdRename(args []string) {
+	fs := newFlags("rename")
+	pattern := fs.String("pattern", "{bc:03} - {name}", "pattern for the new names")
+	apply := fs.Bool("apply", false, "rename the files rather than showing what would happen")
+	undo := fs.Bool("undo", false, "undo the last rename --apply in the directory")
+	recursive := fs.Bool("r", false, "rename in subdirectories too")
+	orderName := fs.String("order", "", "ordering bare numbers are in: bc, viz or nh (default guess for each directory)")
+	args = parseFlags(fs, args)
+	if len(args) != 1 {
+		fmt.Println("rename takes one directory")
+		os.Exit(-1)
+	}
+	dir := args[0]
+
+	if *undo {
+		undoRename(dir, *apply)
+		return
+	}
+
+	if err := checkPattern(*pattern); err != nil {
+		fmt.Printf("Bad pattern: %v\n", err)
+		os.Exit(-1)
+	}
+
+	table := loadTable()
+	hint := parseHintArg(*orderName)
+	var renames []rename
+	targets := make(map[string]string)
+	skipped := 0
+	add := func(from, to string) {
+		if from == to {
+			return
+		}
+		if other, ok := targets[to]; ok {
+			fmt.Printf("Skipping %s: %s is being renamed to %s\n", from, other, to)
+			skipped++
+			return
+		}
+		// The target may only exist already if it is the source under
+		// another case, on a filesystem that ignores case.
+		if target, err := os.Lstat(filepath.Join(dir, to)); err == nil {
+			source, err := os.Lstat(filepath.Join(dir, from))
+			if err != nil || !os.SameFile(source, target) {
+				fmt.Printf("Skipping %s: %s already exists\n", from, to)
+				skipped++
+				return
+			}
+		}
+		targets[to] = from
+		renames = append(renames, rename{From: from, To: to})
+	}
+
+	for _, r := range scanDir(table, dir, *recursive, hint) {
+		rel, _ := filepath.Rel(dir, r.path)
+		switch {
+		case r.id.Episode == nil:
+			fmt.Printf("Skipping %s: %s\n", rel, r.id.Reason)
+			skipped++
+			continue
+		case r.id.Ambiguous():
+			fmt.Printf("Skipping %s: ambiguous (%s)\n", rel, r.id.Reason)
+			skipped++
+			continue
+		}
+
+		stem, err := expandPattern(*pattern, *r.id.Episode)
+		if err != nil {
+			fmt.Printf("Skipping %s: %v\n", rel, err)
+			skipped++
+			continue
+		}
+		if stem == "" {
+			fmt.Printf("Skipping %s: the pattern gives an empty name\n", rel)
+			skipped++
+			continue
+		}
+		relDir := filepath.Dir(rel)
+		oldStem := strings.TrimSuffix(filepath.Base(rel), filepath.Ext(rel))
+		add(rel, filepath.Join(relDir, stem+filepath.Ext(rel)))
+		for _, sub := range sidecars(r.path) {
+			base := filepath.Base(sub)
+			add(filepath.Join(relDir, base), filepath.Join(relDir, stem+strings.TrimPrefix(base, oldStem)))
+		}
+	}
+
+	if len(renames) == 0 {
+		fmt.Println("Nothing to rename")
+		return
+	}
+	for _, r := range renames {
+		fmt.Printf("%s -> %s\n", r.From, r.To)
+	}
+	if !*apply {
+		fmt.Printf("\nDry run: pass --apply to rename %d files\n", len(renames))
+		return
+	}
+
+	done := doRenames(dir, renames)
+	if len(done) > 0 {
+		appendRenameLog(dir, renameBatch{Time: time.Now().UTC().Truncate(time.Second), Renames: done})
+	}
+	fmt.Printf("\nRenamed %d files", len(done))
+	if skipped > 0 {
+		fmt.Printf(", skipped %d", skipped)
+	}
+	fmt.Println()
+	if len(done) < len(renames) {
+		os.Exit(-1)
+	}
+}
+
+// doRenames renames files in dir, stopping at the first failure, and
+// returns those that were renamed.
+func doRenames(dir string, renames []rename) []rename {
+	var done []rename
+	for _, r := range renames {
+		if err := os.Rename(filepath.Join(dir, r.From), filepath.Join(dir, r.To)); err != nil {
+			fmt.Println(err)
+			break
+		}
+		done = append(done, r)
+	}
+	return done
+}
+
+func readRenameLog(dir string) []renameBatch {
+	var batches []renameBatch
+	b, err := os.ReadFile(filepath.Join(dir, renameLog))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+	if err := json.Unmarshal(b, &batches); err != nil {
+		fmt.Printf("%s: %v\n", filepath.Join(dir, renameLog), err)
+		os.Exit(-1)
+	}
+	return batches
+}
+
+func writeRenameLog(dir string, batches []renameBatch) {
+	path := filepath.Join(dir, renameLog)
+	if len(batches) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		return
+	}
+	b, err := json.MarshalIndent(batches, "", "\t")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+	if err := os.WriteFile(path, append(b, '\n'), 0o644); err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+}
+
+func appendRenameLog(dir string, batch renameBatch) {
+	writeRenameLog(dir, append(readRenameLog(dir), batch))
+}
+
+// undoRename reverses the last batch of renames in dir's log. Like rename
+// itself it only shows what it would do unless apply is set.
+func undoRename(dir string, apply bool) {
+	batches := readRenameLog(dir)
+	if len(batches) == 0 {
+		fmt.Printf("Nothing to undo in %s\n", dir)
+		os.Exit(-1)
+	}
+	last := batches[len(batches)-1]
+
+	var undo []rename
+	for i := len(last.Renames) - 1; i >= 0; i-- {
+		r := last.Renames[i]
+		undo = append(undo, rename{From: r.To, To: r.From})
+		fmt.Printf("%s -> %s\n", r.To, r.From)
+	}
+	if !apply {
+		fmt.Printf("\nDry run: pass --apply to undo the renames of %s\n",
+			last.Time.Local().Format("2006-01-02 15:04"))
+		return
+	}
+
+	done := doRenames(dir, undo)
+	if len(done) < len(undo) {
+		// Keep whatever couldn't be undone so that it can be tried again.
+		last.Renames = last.Renames[:len(undo)-len(done)]
+		batches[len(batches)-1] = last
+		writeRenameLog(dir, batches)
+		fmt.Printf("\nUndid %d of %d renames\n", len(done), len(undo))
+		os.Exit(-1)
+	}
+	writeRenameLog(dir, batches[:len(batches)-1])
+	fmt.Printf("\nUndid %d renames\n", len(done))
+}
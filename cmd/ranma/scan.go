@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/japanoise/ranma"
+)
+
+// videoExtensions are the files scan looks at.
+var videoExtensions = map[string]bool{
+	".mkv": true, ".mp4": true, ".m4v": true, ".avi": true, ".webm": true,
+	".mov": true, ".wmv": true, ".ogm": true, ".mpg": true, ".mpeg": true, ".ts": true,
+}
+
+// scanResult is a video file and the episode it appears to be.
+type scanResult struct {
+	path string
+	id   ranma.Identification
+}
+
+// scanDir identifies every video file in dir, in name order. Bare numbers
+// in the names are read in the ordering hint, or if it is nil in whatever
+// ordering the other names in the same directory suggest.
+func scanDir(table *ranma.Table, dir string, recursive bool, hint *ranma.Order) []scanResult {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if videoExtensions[strings.ToLower(filepath.Ext(path))] {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+
+	byDir := make(map[string][]string)
+	for _, path := range paths {
+		byDir[filepath.Dir(path)] = append(byDir[filepath.Dir(path)], filepath.Base(path))
+	}
+	orders := make(map[string]*ranma.Order)
+	for d, names := range byDir {
+		orders[d] = hint
+		if hint == nil {
+			if o, ok := table.GuessOrder(names); ok {
+				orders[d] = &o
+			}
+		}
+	}
+
+	var ret []scanResult
+	for _, path := range paths {
+		r := scanResult{path: path}
+		if o := orders[filepath.Dir(path)]; o != nil {
+			r.id = table.IdentifyIn(path, *o)
+		} else {
+			r.id = table.Identify(path)
+		}
+		ret = append(ret, r)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].path < ret[j].path })
+	return ret
+}
+
+// parseHintArg parses an --order flag saying what ordering bare numbers in
+// file names are in. Empty means to guess for each directory.
+func parseHintArg(s string) *ranma.Order {
+	if s == "" {
+		return nil
+	}
+	o := parseOrderArg(s)
+	return &o
+}
+
+func cmdScan(args []string) {
+	fs := newFlags("scan")
+	recursive := fs.Bool("r", false, "scan subdirectories too")
+	orderName := fs.String("order", "", "ordering bare numbers are in: bc, viz or nh (default guess for each directory)")
+	args = parseFlags(fs, args)
+	requiresArgs(args, 1)
+	table := loadTable()
+	hint := parseHintArg(*orderName)
+
+	var results []scanResult
+	for _, dir := range args {
+		results = append(results, scanDir(table, dir, *recursive, hint)...)
+	}
+	if len(results) == 0 {
+		fmt.Println("No video files found")
+		os.Exit(-1)
+	}
+
+	var unmatched, ambiguous int
+	for _, r := range results {
+		name := filepath.Base(r.path)
+		switch {
+		case r.id.Episode == nil:
+			unmatched++
+			fmt.Printf("UNMATCHED\t%s\t%s\n", name, r.id.Reason)
+		case r.id.Ambiguous():
+			ambiguous++
+			var refs []string
+			for _, c := range r.id.Candidates {
+				refs = append(refs, fmt.Sprintf("bc %d", c.Broadcast()))
+			}
+			fmt.Printf("AMBIGUOUS\t%s\t%s: %s\n", name, r.id.Reason, strings.Join(refs, " or "))
+		default:
+			fmt.Printf("%s\t%s\tBroadcast %d: %s (%s)\n", r.id.Confidence, name,
+				r.id.Episode.Broadcast(), r.id.Episode.Name(), r.id.Reason)
+		}
+	}
+
+	if unmatched > 0 || ambiguous > 0 {
+		fmt.Printf("\n%d of %d files unmatched, %d ambiguous\n", unmatched, len(results), ambiguous)
+	}
+}
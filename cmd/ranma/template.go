@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/japanoise/ranma"
+)
+
+var templateFuncs = template.FuncMap{
+	"jpDate": ranma.JPDate,
+	"fuzzy":  ranma.Fold,
+	"pad":    pad,
+	"rpad":   rpad,
+}
+
+// pad left-pads v to width characters: numbers with zeroes, anything else
+// with spaces.
+func pad(width int, v interface{}) string {
+	if n, ok := v.(int); ok {
+		return fmt.Sprintf("%0*d", width, n)
+	}
+	return fmt.Sprintf("%*s", width, fmt.Sprint(v))
+}
+
+// rpad right-pads v with spaces to width characters.
+func rpad(width int, v interface{}) string {
+	return fmt.Sprintf("%-*s", width, fmt.Sprint(v))
+}
+
+// loadTemplate returns the template given with --template or
+// --template-file, or nil if there isn't one.
+func loadTemplate() *template.Template {
+	text := opts.template
+	if opts.templateFile != "" {
+		if text != "" {
+			fmt.Println("Only one of --template and --template-file can be given")
+			os.Exit(-1)
+		}
+		b, err := os.ReadFile(opts.templateFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		text = string(b)
+	}
+	if text == "" {
+		return nil
+	}
+
+	// One episode per line unless the template says otherwise.
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+
+	tmpl, err := template.New("episode").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		fmt.Printf("Bad template: %v\n", err)
+		os.Exit(-1)
+	}
+	return tmpl
+}
+
+func writeTemplate(list []ranma.Episode, tmpl *template.Template) {
+	for _, epi := range list {
+		if err := tmpl.Execute(os.Stdout, epi); err != nil {
+			fmt.Printf("\nBad template: %v\n", err)
+			os.Exit(-1)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/japanoise/ranma"
+)
+
+// watchStateVersion is the version of the watch state file layout. Version
+// 1 files held a single viewer's watched map and are upgraded on load.
+const watchStateVersion = 2
+
+// defaultProfile is the profile used when --profile isn't given.
+const defaultProfile = "default"
+
+// watchState records which episodes each viewer has watched.
+type watchState struct {
+	Version  int                      `json:"version"`
+	Profiles map[string]*watchProfile `json:"profiles"`
+}
+
+// watchProfile is one viewer's progress. Episodes are keyed by production
+// number so that it doesn't depend on any ordering.
+type watchProfile struct {
+	Watched map[int]time.Time `json:"watched"`
+	History []watchEvent      `json:"history"`
+}
+
+// watchEvent is an entry in a profile's history.
+type watchEvent struct {
+	Production int       `json:"production"`
+	Action     string    `json:"action"` // "watched" or "unwatched"
+	Time       time.Time `json:"time"`
+}
+
+// statePath returns the watch state file, $XDG_DATA_HOME/ranma/watched.json
+// unless --state says otherwise.
+func statePath() string {
+	if opts.state != "" {
+		return opts.state
+	}
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "ranma", "watched.json")
+}
+
+// loadState reads the watch state file. A missing file is an empty state.
+func loadState() *watchState {
+	state := &watchState{Version: watchStateVersion, Profiles: make(map[string]*watchProfile)}
+
+	path := statePath()
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state
+	} else if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+
+	var version struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(b, &version); err != nil {
+		fmt.Printf("%s: %v\n", path, err)
+		os.Exit(-1)
+	}
+
+	switch version.Version {
+	case 1:
+		var old struct {
+			Watched map[int]time.Time `json:"watched"`
+		}
+		err = json.Unmarshal(b, &old)
+		state.Profiles[defaultProfile] = upgradeProfile(old.Watched)
+	case watchStateVersion:
+		err = json.Unmarshal(b, state)
+	default:
+		err = fmt.Errorf("unsupported state version %d (want %d)", version.Version, watchStateVersion)
+	}
+	if err != nil {
+		fmt.Printf("%s: %v\n", path, err)
+		os.Exit(-1)
+	}
+
+	if state.Profiles == nil {
+		state.Profiles = make(map[string]*watchProfile)
+	}
+	for _, p := range state.Profiles {
+		if p.Watched == nil {
+			p.Watched = make(map[int]time.Time)
+		}
+	}
+	return state
+}
+
+// upgradeProfile builds a profile from a version 1 watched map, using the
+// recorded times as its history.
+func upgradeProfile(watched map[int]time.Time) *watchProfile {
+	p := &watchProfile{Watched: make(map[int]time.Time)}
+	for prod, t := range watched {
+		p.Watched[prod] = t
+		p.History = append(p.History, watchEvent{Production: prod, Action: "watched", Time: t})
+	}
+	sort.SliceStable(p.History, func(i, j int) bool {
+		a, b := p.History[i], p.History[j]
+		return a.Time.Before(b.Time) || a.Time.Equal(b.Time) && a.Production < b.Production
+	})
+	return p
+}
+
+// save writes the state file, replacing the old one only once the new one
+// is complete.
+func (s *watchState) save() {
+	path := statePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+
+	b, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+}
+
+// profile returns the profile chosen with --profile, creating it if need be.
+func (s *watchState) profile() *watchProfile {
+	name := profileName()
+	p, ok := s.Profiles[name]
+	if !ok {
+		p = &watchProfile{Watched: make(map[int]time.Time)}
+		s.Profiles[name] = p
+	}
+	return p
+}
+
+func profileName() string {
+	if opts.profile == "" {
+		return defaultProfile
+	}
+	return opts.profile
+}
+
+// names returns the profile names in alphabetical order.
+func (s *watchState) names() []string {
+	var ret []string
+	for name := range s.Profiles {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (p *watchProfile) isWatched(epi ranma.Episode) bool {
+	_, ok := p.Watched[epi.Production()]
+	return ok
+}
+
+func (p *watchProfile) mark(epi ranma.Episode, watched bool, now time.Time) {
+	if watched == p.isWatched(epi) {
+		return
+	}
+	action := "unwatched"
+	if watched {
+		p.Watched[epi.Production()] = now
+		action = "watched"
+	} else {
+		delete(p.Watched, epi.Production())
+	}
+	p.History = append(p.History, watchEvent{Production: epi.Production(), Action: action, Time: now})
+}
+
+func cmdWatched(args []string) {
+	markWatched("watched", true, args)
+}
+
+func cmdUnwatched(args []string) {
+	markWatched("unwatched", false, args)
+}
+
+func markWatched(cmd string, watched bool, args []string) {
+	args = parseFlags(newFlags(cmd), args)
+	requiresArgs(args, 1)
+	table := loadTable()
+
+	list, err := parseRefs(table, args)
+	if err != nil {
+		fmt.Printf("Bad argument: %v\n", err)
+		os.Exit(-1)
+	}
+
+	state := loadState()
+	profile := state.profile()
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, epi := range list {
+		profile.mark(epi, watched, now)
+	}
+	state.save()
+
+	if len(list) == 1 {
+		fmt.Printf("Marked \"%s\" %s\n", list[0].Name(), cmd)
+	} else {
+		fmt.Printf("Marked %d episodes %s\n", len(list), cmd)
+	}
+}
+
+func cmdProgress(args []string) {
+	parseFlags(newFlags("progress"), args)
+	table := loadTable()
+	profile := loadState().profile()
+
+	var seen, total [2]int
+	for _, epi := range table.Episodes() {
+		series := 0
+		if epi.IsNettohen() {
+			series = 1
+		}
+		total[series]++
+		if profile.isWatched(epi) {
+			seen[series]++
+		}
+	}
+
+	line := func(name string, seen, total int) {
+		fmt.Printf("%s\t%d/%d\t%.1f%%\n", name, seen, total, percent(seen, total))
+	}
+	line("Original", seen[0], total[0])
+	line("Nettohen", seen[1], total[1])
+	line("Total", seen[0]+seen[1], total[0]+total[1])
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
+
+func cmdUpNext(args []string) {
+	fs := newFlags("up-next")
+	orderName := fs.String("order", "bc", "ordering to watch in: nh, bc, viz or prod")
+	count := fs.Int("n", 1, "number of episodes to list")
+	parseFlags(fs, args)
+	if *count < 1 {
+		fmt.Println("Bad argument: -n must be at least 1")
+		os.Exit(-1)
+	}
+	table := loadTable()
+	profile := loadState().profile()
+
+	order := parseOrderArg(*orderName)
+	var list []ranma.Episode
+	for _, epi := range table.Sorted(order) {
+		if len(list) >= *count {
+			break
+		}
+		if !profile.isWatched(epi) {
+			list = append(list, epi)
+		}
+	}
+
+	if len(list) == 0 {
+		fmt.Printf("You've watched every episode in %s order!\n", order)
+		return
+	}
+	show(list, *count == 1)
+}
+
+func cmdHistory(args []string) {
+	fs := newFlags("history")
+	count := fs.Int("n", 0, "only show the last N events")
+	parseFlags(fs, args)
+	table := loadTable()
+	profile := loadState().profile()
+
+	history := profile.History
+	if *count > 0 && len(history) > *count {
+		history = history[len(history)-*count:]
+	}
+	if len(history) == 0 {
+		fmt.Printf("Profile %s hasn't watched anything yet\n", profileName())
+		return
+	}
+
+	for _, ev := range history {
+		name := "(unknown episode)"
+		bc := "?"
+		if epi, err := table.ByProduction(ev.Production); err == nil {
+			name = epi.Name()
+			bc = fmt.Sprint(epi.Broadcast())
+		}
+		fmt.Printf("%s\t%s\tBroadcast %s\t%s\n",
+			ev.Time.Local().Format("2006-01-02 15:04"), ev.Action, bc, name)
+	}
+}
@@ -0,0 +1,155 @@
+package ranma
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// DataVersion is the version of the episode data schema understood by Load.
+const DataVersion = 1
+
+// adapted from https://ranma.fandom.com/wiki/List_of_Ranma_%C2%BD_episodes
+//
+//go:embed episodes.json
+var builtinData []byte
+
+var (
+	builtinOnce sync.Once
+	builtin     *Table
+	builtinErr  error
+)
+
+// dataFile is the on-disk layout of an episode data file.
+type dataFile struct {
+	Version  int      `json:"version"`
+	Source   string   `json:"source,omitempty"`
+	Episodes []record `json:"episodes"`
+}
+
+// record is a single episode as stored in a data file. Original series
+// episodes have a null Nettohen number.
+type record struct {
+	Nettohen   *int   `json:"nettohen"`
+	Broadcast  int    `json:"broadcast"`
+	Viz        int    `json:"viz"`
+	Production int    `json:"production"`
+	Name       string `json:"name"`
+	RomajiName string `json:"rjname"`
+	JPName     string `json:"jpname"`
+	Date       string `json:"date"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+// ParseDate parses a date written the Japanese way, i.e. YYYY-MM-DD.
+func ParseDate(date string) (time.Time, error) {
+	return time.Parse("2006-01-02", date)
+}
+
+func (r *record) episode() (Episode, error) {
+	ret := Episode{
+		nettohen:   -1,
+		production: r.Production,
+		broadcast:  r.Broadcast,
+		viz:        r.Viz,
+		name:       r.Name,
+		rjname:     r.RomajiName,
+		jpname:     r.JPName,
+		notes:      r.Notes,
+	}
+	if r.Nettohen != nil {
+		if *r.Nettohen < 1 {
+			return ret, fmt.Errorf("invalid Nettohen number %d", *r.Nettohen)
+		}
+		ret.nettohen = *r.Nettohen
+	}
+	if r.Broadcast < 1 || r.Viz < 1 || r.Production < 1 {
+		return ret, fmt.Errorf("broadcast, viz and production numbers must be positive, got %d/%d/%d",
+			r.Broadcast, r.Viz, r.Production)
+	}
+	if r.Name == "" {
+		return ret, errors.New("missing English title")
+	}
+
+	pdate, err := ParseDate(r.Date)
+	if err != nil {
+		return ret, fmt.Errorf("invalid date %q: %w", r.Date, err)
+	}
+	ret.date = pdate
+
+	return ret, nil
+}
+
+// Load reads an episode data file in the format of the built-in data.
+// Unknown keys are errors, so that a misspelt field isn't silently dropped.
+func Load(r io.Reader) (*Table, error) {
+	var file dataFile
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, err
+	}
+	if file.Version != DataVersion {
+		return nil, fmt.Errorf("unsupported data version %d (want %d)", file.Version, DataVersion)
+	}
+
+	episodes := make([]Episode, 0, len(file.Episodes))
+	for i := range file.Episodes {
+		epi, err := file.Episodes[i].episode()
+		if err != nil {
+			return nil, fmt.Errorf("episode %d: %w", i+1, err)
+		}
+		episodes = append(episodes, epi)
+	}
+
+	return newTable(episodes)
+}
+
+// newTable checks that no two episodes share a number in any ordering.
+func newTable(episodes []Episode) (*Table, error) {
+	seen := make(map[string]map[int]bool)
+	check := func(order string, n int) error {
+		if n < 1 {
+			return nil
+		}
+		if seen[order] == nil {
+			seen[order] = make(map[int]bool)
+		}
+		if seen[order][n] {
+			return fmt.Errorf("duplicate %s number %d", order, n)
+		}
+		seen[order][n] = true
+		return nil
+	}
+
+	for _, epi := range episodes {
+		for _, err := range []error{
+			check("Nettohen", epi.nettohen),
+			check("broadcast", epi.broadcast),
+			check("Viz", epi.viz),
+			check("production", epi.production),
+		} {
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &Table{episodes: episodes}, nil
+}
+
+// Builtin returns the table of episodes shipped with the package.
+func Builtin() (*Table, error) {
+	builtinOnce.Do(func() {
+		builtin, builtinErr = Load(bytes.NewReader(builtinData))
+		if builtinErr != nil {
+			builtinErr = fmt.Errorf("built-in episode data: %w", builtinErr)
+		}
+	})
+	return builtin, builtinErr
+}
@@ -0,0 +1,25 @@
+package ranma
+
+import "time"
+
+// AiredBetween returns the episodes first aired between from and to,
+// inclusive. Only the dates are compared, not the times.
+func (t *Table) AiredBetween(from, to time.Time) []Episode {
+	first, last := dayOf(from), dayOf(to)
+	return t.Filter(func(ep Episode) bool {
+		day := dayOf(ep.date)
+		return !day.Before(first) && !day.After(last)
+	})
+}
+
+// AiredOnDay returns the episodes first aired on the given month and day of
+// any year, for anniversaries.
+func (t *Table) AiredOnDay(month time.Month, day int) []Episode {
+	return t.Filter(func(ep Episode) bool {
+		return ep.date.Month() == month && ep.date.Day() == day
+	})
+}
+
+func dayOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
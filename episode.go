@@ -0,0 +1,110 @@
+// Package ranma provides the Ranma ½ anime episode table and ways to query it.
+package ranma
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Episode is a single episode of the anime. Episodes of the original series
+// have no Nettohen number, and their Viz number is the same as their
+// broadcast number.
+type Episode struct {
+	nettohen   int
+	production int
+	broadcast  int
+	viz        int
+	name       string
+	rjname     string
+	jpname     string
+	date       time.Time
+	notes      string
+}
+
+// Nettohen returns the episode's Nettohen number, or -1 if the episode is
+// part of the original series.
+func (e Episode) Nettohen() int { return e.nettohen }
+
+// Production returns the episode's position in production order.
+func (e Episode) Production() int { return e.production }
+
+// Broadcast returns the episode's position in broadcast order.
+func (e Episode) Broadcast() int { return e.broadcast }
+
+// Viz returns the episode's position in the Viz home release order.
+func (e Episode) Viz() int { return e.viz }
+
+// Name returns the English title.
+func (e Episode) Name() string { return e.name }
+
+// RomajiName returns the Japanese title in romaji.
+func (e Episode) RomajiName() string { return e.rjname }
+
+// JapaneseName returns the Japanese title.
+func (e Episode) JapaneseName() string { return e.jpname }
+
+// Date returns the date the episode first aired.
+func (e Episode) Date() time.Time { return e.date }
+
+// Notes returns any free-form notes attached to the episode.
+func (e Episode) Notes() string { return e.notes }
+
+// IsNettohen reports whether the episode is part of Ranma ½ Nettohen rather
+// than the original series.
+func (e Episode) IsNettohen() bool { return e.nettohen > 0 }
+
+// Reordered reports whether the episode's broadcast, Viz and production
+// numbers don't all agree.
+func (e Episode) Reordered() bool {
+	return e.broadcast != e.viz || e.viz != e.production
+}
+
+func (e Episode) String() string {
+	ret := ""
+	if e.IsNettohen() {
+		ret += fmt.Sprintf("Nettohen Episode %d, Broadcast Episode %d, Viz Episode %d, Production Episode %d\n",
+			e.nettohen, e.broadcast, e.viz, e.production)
+	} else {
+		ret += fmt.Sprintf("Broadcast Episode %d, Production Episode %d\n",
+			e.broadcast, e.production)
+	}
+	ret += fmt.Sprintf("English title: %s\n", e.name)
+	ret += fmt.Sprintf("Japanese title: %s (%s)\n", e.jpname, e.rjname)
+	ret += fmt.Sprintf("First aired %s", JPDate(e.date))
+	if e.notes != "" {
+		ret += fmt.Sprintf("\nNotes: %s", e.notes)
+	}
+	return ret
+}
+
+// JPDate formats date the Japanese way, i.e. YYYY-MM-DD.
+func JPDate(date time.Time) string {
+	return date.Format("2006-01-02")
+}
+
+// MarshalJSON encodes the episode with the same fields as the data file:
+// nettohen, broadcast, viz, production, name, rjname, jpname, date and
+// notes. The Nettohen number is null for the original series and the date
+// is YYYY-MM-DD.
+func (e Episode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.record())
+}
+
+func (e Episode) record() record {
+	ret := record{
+		Broadcast:  e.broadcast,
+		Viz:        e.viz,
+		Production: e.production,
+		Name:       e.name,
+		RomajiName: e.rjname,
+		JPName:     e.jpname,
+		Date:       JPDate(e.date),
+		Notes:      e.notes,
+	}
+	if e.IsNettohen() {
+		nh := e.nettohen
+		ret.Nettohen = &nh
+	}
+	return ret
+}
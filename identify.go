@@ -0,0 +1,283 @@
+package ranma
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SeasonEpisode returns the episode's season and episode number when the
+// anime is split into two seasons: the original series, numbered in
+// broadcast order, and Nettohen, numbered by Nettohen number.
+func (e Episode) SeasonEpisode() (season, episode int) {
+	if e.IsNettohen() {
+		return 2, e.nettohen
+	}
+	return 1, e.broadcast
+}
+
+// BySeasonEpisode finds an episode by its season and episode number; see
+// Episode.SeasonEpisode.
+func (t *Table) BySeasonEpisode(season, episode int) (*Episode, error) {
+	return t.Find(func(ep Episode) bool {
+		s, e := ep.SeasonEpisode()
+		return s == season && e == episode
+	})
+}
+
+// Confidence is how sure Identify is of its answer.
+type Confidence int
+
+const (
+	ConfidenceNone   Confidence = iota // nothing matched
+	ConfidenceLow                      // a guess; the name could mean several episodes
+	ConfidenceMedium                   // a bare number or a title alone
+	ConfidenceHigh                     // an explicit ordering, or a number and title that agree
+)
+
+func (c Confidence) String() string {
+	switch c {
+	case ConfidenceLow:
+		return "low"
+	case ConfidenceMedium:
+		return "medium"
+	case ConfidenceHigh:
+		return "high"
+	}
+	return "none"
+}
+
+// Identification is the result of Identify.
+type Identification struct {
+	Episode    *Episode // nil if nothing matched
+	Confidence Confidence
+	// How the file name was read, e.g. "broadcast 45" or "S02E07".
+	Reason string
+	// Every episode the name could refer to when it is ambiguous.
+	Candidates []Episode
+}
+
+// Ambiguous reports whether the name could refer to more than one episode.
+func (id Identification) Ambiguous() bool {
+	return len(id.Candidates) > 1
+}
+
+var (
+	bracketPattern  = regexp.MustCompile(`\[[^\]]*\]|\{[^}]*\}|\([^)]*\)`)
+	titlePattern    = regexp.MustCompile(`(?i)\branma(\s*(1(\s*[/-]\s*|\s+)2\b|½|one[\s-]*half))?`)
+	seasonPattern   = regexp.MustCompile(`(?i)\bS(\d{1,2})\s*E(\d{1,3})\b|\b(\d{1,2})x(\d{2,3})\b`)
+	orderPattern    = regexp.MustCompile(`(?i)\b(nh|nettohen|bc|broadcast|viz|prod|production)\s*[#.-]?\s*(\d{1,3})\b`)
+	numberPattern   = regexp.MustCompile(`(?i)(?:\b(?:ep?|episode)\s*|#|\b)(\d{1,3})(?:v\d)?\b`)
+	nettohenPattern = regexp.MustCompile(`(?i)\bnettou?hen\b`)
+	noisePattern    = regexp.MustCompile(`(?i)\b(dvd|bd|bluray|blu-ray|web|webrip|web-dl|rip|remux|x26[45]|h\.?26[45]|hevc|avc|aac|ac3|flac|\d{3,4}p|dual|audio|multi|subs?|dub(bed)?|eng|jpn?|raw|v\d)\b`)
+)
+
+// Identify guesses which episode a video file name refers to, such as
+// "[Group] Ranma 1-2 - 045 [DVD].mkv" or "Ranma.S02E07.mkv". Bare numbers
+// are read in broadcast order, or as Nettohen numbers if the name says
+// "Nettohen"; if the Viz number would mean a different episode the result
+// is ambiguous unless a title in the name settles it. IdentifyIn reads
+// them in a known ordering instead.
+func (t *Table) Identify(filename string) Identification {
+	return t.identify(parseFileName(filename), nil)
+}
+
+// IdentifyIn is like Identify, but reads a bare number in the ordering o,
+// such as when every file in a directory is known to use Viz numbers; see
+// GuessOrder. Names that say "Nettohen", or give an ordering or a season
+// themselves, are read as Identify reads them.
+func (t *Table) IdentifyIn(filename string, o Order) Identification {
+	return t.identify(parseFileName(filename), &o)
+}
+
+// GuessOrder guesses the ordering that the bare numbers in a set of file
+// names, such as the videos in one directory, are in. A name with an
+// explicit ordering counts for that ordering, S01 episodes count for
+// broadcast order, and a bare number with a title counts for whichever of
+// broadcast, Viz and Nettohen order give the titled episode that number.
+// It reports false if nothing counts for any ordering or two orderings
+// tie.
+func (t *Table) GuessOrder(filenames []string) (Order, bool) {
+	votes := make(map[Order]int)
+	for _, filename := range filenames {
+		n := parseFileName(filename)
+		switch {
+		case n.season != nil:
+			if season, _ := strconv.Atoi(n.season[0]); season == 1 {
+				votes[OrderBroadcast]++
+			}
+		case n.order != nil:
+			order, _ := ParseOrder(n.order[0])
+			votes[order]++
+		case n.number > 0 && !n.nettohen:
+			title, _ := t.identifyTitle(n.rest)
+			if title == nil {
+				continue
+			}
+			for _, o := range []Order{OrderBroadcast, OrderViz, OrderNettohen} {
+				if o.Number(*title) == n.number {
+					votes[o]++
+				}
+			}
+		}
+	}
+
+	var best Order
+	most, tied := 0, false
+	for _, o := range Orders {
+		switch {
+		case votes[o] > most:
+			best, most, tied = o, votes[o], false
+		case votes[o] == most && most > 0:
+			tied = true
+		}
+	}
+	return best, most > 0 && !tied
+}
+
+// fileName is a video file name broken into the parts Identify reads.
+type fileName struct {
+	nettohen bool     // the name says "Nettohen"
+	season   []string // a season and episode, e.g. S02E07, or nil
+	order    []string // an explicit ordering and number, e.g. viz 40, or nil
+	number   int      // a bare number, or 0
+	rest     string   // what's left of the name, which may be a title
+}
+
+func parseFileName(filename string) fileName {
+	var ret fileName
+	name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	name = bracketPattern.ReplaceAllString(name, " ")
+	name = strings.NewReplacer(".", " ", "_", " ").Replace(name)
+	ret.nettohen = nettohenPattern.MatchString(name)
+	name = titlePattern.ReplaceAllString(name, " ")
+
+	if m := seasonPattern.FindStringSubmatch(name); m != nil {
+		ret.season = []string{m[1], m[2]}
+		if m[1] == "" {
+			ret.season = []string{m[3], m[4]}
+		}
+	}
+	if m := orderPattern.FindStringSubmatch(name); m != nil {
+		ret.order = m[1:]
+	}
+
+	ret.rest = noisePattern.ReplaceAllString(name, " ")
+	if loc := numberPattern.FindStringSubmatchIndex(ret.rest); loc != nil {
+		ret.number, _ = strconv.Atoi(ret.rest[loc[2]:loc[3]])
+		ret.rest = ret.rest[:loc[0]] + " " + ret.rest[loc[1]:]
+	}
+	return ret
+}
+
+// identify identifies a parsed file name, reading a bare number in the
+// ordering hint if it isn't nil.
+func (t *Table) identify(name fileName, hint *Order) Identification {
+	// Explicit season and episode, e.g. S02E07 or 2x07.
+	if name.season != nil {
+		season, _ := strconv.Atoi(name.season[0])
+		episode, _ := strconv.Atoi(name.season[1])
+		reason := fmt.Sprintf("S%02dE%02d", season, episode)
+		if epi, err := t.BySeasonEpisode(season, episode); err == nil {
+			return Identification{Episode: epi, Confidence: ConfidenceHigh, Reason: reason}
+		}
+		// Some releases number everything as one long season.
+		if season == 1 {
+			if epi, err := t.By(OrderBroadcast, episode); err == nil {
+				return Identification{Episode: epi, Confidence: ConfidenceLow, Reason: reason + " as broadcast " + name.season[1]}
+			}
+		}
+		return Identification{Reason: reason + " isn't an episode"}
+	}
+
+	// An explicit ordering, e.g. "viz 40".
+	if name.order != nil {
+		order, _ := ParseOrder(name.order[0])
+		n, _ := strconv.Atoi(name.order[1])
+		reason := fmt.Sprintf("%s %d", order, n)
+		if epi, err := t.By(order, n); err == nil {
+			return Identification{Episode: epi, Confidence: ConfidenceHigh, Reason: reason}
+		}
+		return Identification{Reason: reason + " isn't an episode"}
+	}
+
+	// Otherwise a bare number and whatever's left, which may be a title.
+	var candidates []Episode
+	var reason string
+	if name.number > 0 {
+		orders := []Order{OrderBroadcast, OrderViz}
+		switch {
+		case name.nettohen:
+			orders = []Order{OrderNettohen}
+		case hint != nil:
+			orders = []Order{*hint}
+		}
+		reason = fmt.Sprintf("%s %d", orders[0], name.number)
+		for _, o := range orders {
+			epi, err := t.By(o, name.number)
+			if err != nil || containsEpisode(candidates, *epi) {
+				continue
+			}
+			candidates = append(candidates, *epi)
+		}
+	}
+
+	title, titleScore := t.identifyTitle(name.rest)
+
+	switch {
+	case title != nil && len(candidates) > 0:
+		if containsEpisode(candidates, *title) {
+			return Identification{Episode: title, Confidence: ConfidenceHigh, Reason: reason + " and title"}
+		}
+		// The number and title disagree; trust neither much.
+		all := append(candidates, *title)
+		return Identification{Episode: &candidates[0], Confidence: ConfidenceLow, Reason: reason + ", but the title doesn't match", Candidates: all}
+	case len(candidates) == 1:
+		return Identification{Episode: &candidates[0], Confidence: ConfidenceMedium, Reason: reason}
+	case len(candidates) > 1:
+		return Identification{Episode: &candidates[0], Confidence: ConfidenceLow,
+			Reason: reason + ", or Viz order", Candidates: candidates}
+	case title != nil:
+		conf := ConfidenceLow
+		if titleScore >= 0.85 {
+			conf = ConfidenceMedium
+		}
+		return Identification{Episode: title, Confidence: conf, Reason: "title"}
+	}
+
+	if reason != "" {
+		return Identification{Reason: reason + " isn't an episode"}
+	}
+	return Identification{Reason: "no episode number or title"}
+}
+
+// identifyTitle looks for an English or romaji title in what's left of a
+// file name.
+func (t *Table) identifyTitle(rest string) (*Episode, float64) {
+	if len(FieldName.words(rest)) < 2 {
+		return nil, 0
+	}
+
+	var best *Match
+	for _, f := range []Field{FieldName, FieldRomaji} {
+		matches := t.Search(rest, f)
+		if len(matches) > 0 && (best == nil || matches[0].Score > best.Score) {
+			best = &matches[0]
+		}
+	}
+	if best == nil || best.Score < 0.75 {
+		return nil, 0
+	}
+	return &best.Episode, best.Score
+}
+
+func containsEpisode(list []Episode, epi Episode) bool {
+	for _, e := range list {
+		if e.production == epi.production {
+			return true
+		}
+	}
+	return false
+}
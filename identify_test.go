@@ -0,0 +1,113 @@
+package ranma
+
+import "testing"
+
+func TestIdentify(t *testing.T) {
+	table, err := Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file       string
+		bc         int // 0 if nothing should match
+		confidence Confidence
+		ambiguous  bool
+	}{
+		{"[Group] Ranma 1-2 - 045 [DVD].mkv", 45, ConfidenceMedium, false},
+		{"Ranma_1_2_-_045_[DVD].mkv", 45, ConfidenceMedium, false},
+		{"Ranma.1.2.-.045.mkv", 45, ConfidenceMedium, false},
+		{"[Grp] Ranma 1-2 - 045v2.mkv", 45, ConfidenceMedium, false},
+		{"Ranma 12.mkv", 12, ConfidenceMedium, false},
+		{"Ranma.S01E05.mkv", 5, ConfidenceHigh, false},
+		{"Ranma.S02E07.mkv", 25, ConfidenceHigh, false},
+		{"Ranma 1x18.mkv", 18, ConfidenceHigh, false},
+		{"Ranma Nettohen 12.mp4", 30, ConfidenceHigh, false},
+		{"Ranma viz 40.avi", 40, ConfidenceHigh, false},
+		{"Ranma ½ - 025 - The Abduction of P-Chan [720p].mkv", 25, ConfidenceHigh, false},
+		{"Ranma 1-2 - 025 [BD 1080p x264].mkv", 25, ConfidenceLow, true},
+		{"ranma - Bathhouse Battle.mkv", 40, ConfidenceMedium, false},
+		{"Ranma 250.mkv", 0, ConfidenceNone, false},
+		{"S03E02.mkv", 0, ConfidenceNone, false},
+		{"random.mkv", 0, ConfidenceNone, false},
+	}
+	for _, tt := range tests {
+		id := table.Identify(tt.file)
+		bc := 0
+		if id.Episode != nil {
+			bc = id.Episode.Broadcast()
+		}
+		if bc != tt.bc || id.Confidence != tt.confidence || id.Ambiguous() != tt.ambiguous {
+			t.Errorf("Identify(%q) = broadcast %d, %s confidence, ambiguous %v (%s); want broadcast %d, %s, %v",
+				tt.file, bc, id.Confidence, id.Ambiguous(), id.Reason, tt.bc, tt.confidence, tt.ambiguous)
+		}
+	}
+}
+
+func TestIdentifyIn(t *testing.T) {
+	table, err := Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file       string
+		order      Order
+		bc         int
+		confidence Confidence
+	}{
+		{"Ranma 1-2 - 025 [BD 1080p x264].mkv", OrderBroadcast, 25, ConfidenceMedium},
+		{"Ranma 1-2 - 025 [BD 1080p x264].mkv", OrderViz, 22, ConfidenceMedium},
+		{"Ranma 1-2 - 019 - The Abduction of P-Chan.mkv", OrderViz, 25, ConfidenceHigh},
+		{"Ranma 12.mkv", OrderNettohen, 30, ConfidenceMedium},
+		{"Ranma Nettohen Ep05.mkv", OrderViz, 23, ConfidenceMedium},
+		{"Ranma viz 40.avi", OrderBroadcast, 40, ConfidenceHigh},
+		{"Ranma.S02E07.mkv", OrderViz, 25, ConfidenceHigh},
+		{"Ranma 250.mkv", OrderViz, 0, ConfidenceNone},
+	}
+	for _, tt := range tests {
+		id := table.IdentifyIn(tt.file, tt.order)
+		bc := 0
+		if id.Episode != nil {
+			bc = id.Episode.Broadcast()
+		}
+		if bc != tt.bc || id.Confidence != tt.confidence || id.Ambiguous() {
+			t.Errorf("IdentifyIn(%q, %s) = broadcast %d, %s confidence, ambiguous %v (%s); want broadcast %d, %s",
+				tt.file, tt.order, bc, id.Confidence, id.Ambiguous(), id.Reason, tt.bc, tt.confidence)
+		}
+	}
+}
+
+func TestGuessOrder(t *testing.T) {
+	table, err := Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		files []string
+		order Order
+		ok    bool
+	}{
+		{[]string{
+			"[Grp] Ranma 1-2 - 019 - The Abduction of P-Chan.mkv",
+			"[Grp] Ranma 1-2 - 020 - Close Call! The Dance of Death... On Ice!.mkv",
+			"[Grp] Ranma 1-2 - 022.mkv",
+		}, OrderViz, true},
+		{[]string{
+			"Ranma 025 - The Abduction of P-Chan.mkv",
+			"Ranma 026.mkv",
+		}, OrderBroadcast, true},
+		{[]string{"Ranma viz 40.avi", "Ranma 41.avi"}, OrderViz, true},
+		{[]string{"Ranma.S01E05.mkv", "Ranma 025.mkv"}, OrderBroadcast, true},
+		{[]string{"Ranma 025.mkv", "Ranma 026.mkv"}, 0, false},
+		{[]string{"Ranma viz 40.avi", "Ranma bc 41.avi"}, 0, false},
+		{nil, 0, false},
+	}
+	for _, tt := range tests {
+		order, ok := table.GuessOrder(tt.files)
+		if ok != tt.ok || ok && order != tt.order {
+			t.Errorf("GuessOrder(%q) = %s, %v; want %s, %v", tt.files, order, ok, tt.order, tt.ok)
+		}
+	}
+}
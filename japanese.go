@@ -0,0 +1,73 @@
+package ranma
+
+import (
+	"strings"
+	"unicode"
+)
+
+// halfWidthKana maps the half-width katakana block, starting at U+FF66, to
+// full-width katakana.
+var halfWidthKana = []rune("ヲァィゥェォャュョッーアイウエオカキクケコサシスセソタチツテトナニヌネノハヒフヘホマミムメモヤユヨラリルレロワン")
+
+// NormalizeJapanese folds a Japanese title for matching: full-width and
+// half-width forms become their usual widths, katakana becomes hiragana,
+// Latin letters are lowercased, and punctuation becomes single spaces.
+func NormalizeJapanese(s string) string {
+	in := []rune(s)
+	var b strings.Builder
+	space := true
+	for i := 0; i < len(in); i++ {
+		r := in[i]
+		switch {
+		case r >= 0xFF01 && r <= 0xFF5E:
+			// Full-width ASCII
+			r -= 0xFEE0
+		case r >= 0xFF66 && r <= 0xFF9D:
+			r = halfWidthKana[r-0xFF66]
+			if i+1 < len(in) {
+				if v := voice(r, in[i+1]); v != r {
+					r = v
+					i++
+				}
+			}
+		}
+
+		if r >= 0x30A1 && r <= 0x30F6 {
+			// Katakana to hiragana; ヴ and the small ヵヶ have no
+			// common hiragana form but are mapped all the same.
+			r -= 0x60
+		}
+		r = unicode.ToLower(r)
+
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+		} else if !space {
+			b.WriteRune(' ')
+			space = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), " ")
+}
+
+// voice applies a half-width (semi-)voiced sound mark to a katakana, or
+// returns it unchanged if mark isn't one or doesn't apply.
+func voice(r, mark rune) rune {
+	switch mark {
+	case 0xFF9E: // ﾞ
+		switch {
+		case r == 'ウ':
+			return 'ヴ'
+		case r >= 'カ' && r <= 'チ' && (r-'カ')%2 == 0,
+			r >= 'ツ' && r <= 'ト' && (r-'ツ')%2 == 0:
+			return r + 1
+		case r >= 'ハ' && r <= 'ホ' && (r-'ハ')%3 == 0:
+			return r + 1
+		}
+	case 0xFF9F: // ﾟ
+		if r >= 'ハ' && r <= 'ホ' && (r-'ハ')%3 == 0 {
+			return r + 2
+		}
+	}
+	return r
+}
@@ -0,0 +1,23 @@
+package ranma
+
+import "testing"
+
+func TestNormalizeJapanese(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"ﾔｯﾊﾟﾘ猫が嫌い", "やっぱり猫が嫌い"},
+		{"ヤッパリ猫が嫌い", "やっぱり猫が嫌い"},
+		{"やっぱり猫が嫌い", "やっぱり猫が嫌い"},
+		{"ｶﾞｯｺｳ", "がっこう"},
+		{"らんま！", "らんま"},
+		{"乙女白書·髪は女のいのちなの", "乙女白書 髪は女のいのちなの"},
+		{"いい湯だな?銭湯で戦闘", "いい湯だな 銭湯で戦闘"},
+		{"ＡＢＣ１２", "abc12"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeJapanese(tt.in); got != tt.want {
+			t.Errorf("NormalizeJapanese(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
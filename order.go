@@ -0,0 +1,189 @@
+package ranma
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Order is one of the ways the episodes are numbered.
+type Order int
+
+const (
+	OrderNettohen   Order = iota // Nettohen episodes only
+	OrderBroadcast               // Japanese broadcast order
+	OrderViz                     // Viz home release order
+	OrderProduction              // production order
+)
+
+// Orders lists every ordering.
+var Orders = []Order{OrderNettohen, OrderBroadcast, OrderViz, OrderProduction}
+
+func (o Order) String() string {
+	switch o {
+	case OrderNettohen:
+		return "Nettohen"
+	case OrderBroadcast:
+		return "broadcast"
+	case OrderViz:
+		return "Viz"
+	case OrderProduction:
+		return "production"
+	}
+	return "unknown"
+}
+
+// Short returns the abbreviation used for o on the command line.
+func (o Order) Short() string {
+	switch o {
+	case OrderNettohen:
+		return "nh"
+	case OrderBroadcast:
+		return "bc"
+	case OrderViz:
+		return "viz"
+	case OrderProduction:
+		return "prod"
+	}
+	return "unknown"
+}
+
+// ParseOrder parses an ordering's name or abbreviation, e.g. "bc" or
+// "broadcast".
+func ParseOrder(s string) (Order, error) {
+	switch strings.ToLower(s) {
+	case "nh", "nettohen":
+		return OrderNettohen, nil
+	case "bc", "broadcast":
+		return OrderBroadcast, nil
+	case "viz":
+		return OrderViz, nil
+	case "prod", "production":
+		return OrderProduction, nil
+	}
+	return 0, fmt.Errorf("unknown ordering %q (want nh, bc, viz or prod)", s)
+}
+
+// Number returns e's position in the ordering, or -1 if it has none.
+func (o Order) Number(e Episode) int {
+	switch o {
+	case OrderNettohen:
+		return e.nettohen
+	case OrderBroadcast:
+		return e.broadcast
+	case OrderViz:
+		return e.viz
+	case OrderProduction:
+		return e.production
+	}
+	return -1
+}
+
+// By finds an episode by its position in the ordering.
+func (t *Table) By(o Order, n int) (*Episode, error) {
+	if n < 1 {
+		return nil, ErrNotFound
+	}
+	return t.Find(func(ep Episode) bool { return o.Number(ep) == n })
+}
+
+// Range returns the lowest and highest numbers used in the ordering.
+func (t *Table) Range(o Order) (first, last int) {
+	for _, epi := range t.episodes {
+		n := o.Number(epi)
+		if n < 1 {
+			continue
+		}
+		if first == 0 || n < first {
+			first = n
+		}
+		if n > last {
+			last = n
+		}
+	}
+	return first, last
+}
+
+// Sorted returns the episodes that have a number in the ordering, sorted by
+// it.
+func (t *Table) Sorted(o Order) []Episode {
+	ret := t.Filter(func(ep Episode) bool { return o.Number(ep) > 0 })
+	sort.SliceStable(ret, func(i, j int) bool { return o.Number(ret[i]) < o.Number(ret[j]) })
+	return ret
+}
+
+// Shift is an episode that comes at a different place in two orderings.
+// From and To are its positions among the episodes the orderings share,
+// counting from 1.
+type Shift struct {
+	Episode  Episode
+	From, To int
+}
+
+// Compare lists the episodes that are watched at a different point in
+// ordering b than in ordering a, in order a. Only episodes that are in both
+// orderings count, so comparing Nettohen with broadcast order isn't thrown
+// off by the original series.
+func (t *Table) Compare(a, b Order) []Shift {
+	inBoth := func(ep Episode) bool { return a.Number(ep) > 0 && b.Number(ep) > 0 }
+
+	position := make(map[int]int)
+	i := 0
+	for _, ep := range t.Sorted(b) {
+		if inBoth(ep) {
+			i++
+			position[ep.production] = i
+		}
+	}
+
+	var ret []Shift
+	i = 0
+	for _, ep := range t.Sorted(a) {
+		if !inBoth(ep) {
+			continue
+		}
+		i++
+		if to := position[ep.production]; to != i {
+			ret = append(ret, Shift{Episode: ep, From: i, To: to})
+		}
+	}
+	return ret
+}
+
+// Next returns up to count episodes that follow episode n in the ordering.
+// count must be at least 1.
+func (t *Table) Next(o Order, n, count int) ([]Episode, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("invalid count %d", count)
+	}
+	list := t.Sorted(o)
+	for i, ep := range list {
+		if o.Number(ep) == n {
+			end := i + 1 + count
+			if end > len(list) {
+				end = len(list)
+			}
+			return list[i+1 : end], nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Prev returns up to count episodes that come before episode n in the
+// ordering, in that ordering. count must be at least 1.
+func (t *Table) Prev(o Order, n, count int) ([]Episode, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("invalid count %d", count)
+	}
+	list := t.Sorted(o)
+	for i, ep := range list {
+		if o.Number(ep) == n {
+			start := i - count
+			if start < 0 {
+				start = 0
+			}
+			return list[start:i], nil
+		}
+	}
+	return nil, ErrNotFound
+}
@@ -0,0 +1,217 @@
+package ranma
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// Overlay is a set of local corrections and additions to an episode table,
+// keyed by production number.
+type Overlay struct {
+	entries []overlayRecord
+}
+
+// overlayRecord is a record in which every field but the production number
+// is optional. Fields that are left out keep their value from the table the
+// overlay is applied to. Unknown keys are errors, so that a misspelt field
+// isn't silently ignored.
+type overlayRecord struct {
+	Nettohen   *int    `json:"nettohen"`
+	Broadcast  *int    `json:"broadcast"`
+	Viz        *int    `json:"viz"`
+	Production int     `json:"production"`
+	Name       *string `json:"name"`
+	RomajiName *string `json:"rjname"`
+	JPName     *string `json:"jpname"`
+	Date       *string `json:"date"`
+	Notes      *string `json:"notes"`
+}
+
+// LoadOverlay reads an overlay file. It has the same layout as the built-in
+// data, but only the production number is required in each entry.
+func LoadOverlay(r io.Reader) (*Overlay, error) {
+	var file struct {
+		Version  int             `json:"version"`
+		Source   string          `json:"source"`
+		Episodes []overlayRecord `json:"episodes"`
+	}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, err
+	}
+	if file.Version != DataVersion {
+		return nil, fmt.Errorf("unsupported data version %d (want %d)", file.Version, DataVersion)
+	}
+	for i, rec := range file.Episodes {
+		if rec.Production < 1 {
+			return nil, fmt.Errorf("episode %d: missing production number", i+1)
+		}
+	}
+	return &Overlay{entries: file.Episodes}, nil
+}
+
+// Apply returns a new table with the overlay merged over t. Entries whose
+// production number isn't in t are added as new episodes, and must then be
+// complete.
+func (t *Table) Apply(o *Overlay) (*Table, error) {
+	episodes := t.Episodes()
+	index := make(map[int]int, len(episodes))
+	for i, epi := range episodes {
+		index[epi.production] = i
+	}
+
+	for _, rec := range o.entries {
+		i, ok := index[rec.Production]
+		if !ok {
+			epi, err := rec.full().episode()
+			if err != nil {
+				return nil, fmt.Errorf("new production episode %d: %w", rec.Production, err)
+			}
+			index[rec.Production] = len(episodes)
+			episodes = append(episodes, epi)
+			continue
+		}
+
+		merged, err := rec.apply(episodes[i])
+		if err != nil {
+			return nil, fmt.Errorf("production episode %d: %w", rec.Production, err)
+		}
+		episodes[i] = merged
+	}
+
+	sort.SliceStable(episodes, func(i, j int) bool {
+		return episodes[i].broadcast < episodes[j].broadcast
+	})
+	return newTable(episodes)
+}
+
+func (rec *overlayRecord) full() *record {
+	ret := &record{Nettohen: rec.Nettohen, Production: rec.Production}
+	if rec.Broadcast != nil {
+		ret.Broadcast = *rec.Broadcast
+	}
+	if rec.Viz != nil {
+		ret.Viz = *rec.Viz
+	}
+	if rec.Name != nil {
+		ret.Name = *rec.Name
+	}
+	if rec.RomajiName != nil {
+		ret.RomajiName = *rec.RomajiName
+	}
+	if rec.JPName != nil {
+		ret.JPName = *rec.JPName
+	}
+	if rec.Date != nil {
+		ret.Date = *rec.Date
+	}
+	if rec.Notes != nil {
+		ret.Notes = *rec.Notes
+	}
+	return ret
+}
+
+func (rec *overlayRecord) apply(epi Episode) (Episode, error) {
+	if rec.Nettohen != nil {
+		if *rec.Nettohen < 1 {
+			return epi, fmt.Errorf("invalid Nettohen number %d", *rec.Nettohen)
+		}
+		epi.nettohen = *rec.Nettohen
+	}
+	for _, n := range []*int{rec.Broadcast, rec.Viz} {
+		if n != nil && *n < 1 {
+			return epi, fmt.Errorf("invalid episode number %d", *n)
+		}
+	}
+	if rec.Broadcast != nil {
+		epi.broadcast = *rec.Broadcast
+	}
+	if rec.Viz != nil {
+		epi.viz = *rec.Viz
+	}
+	if rec.Name != nil {
+		if *rec.Name == "" {
+			return epi, errors.New("missing English title")
+		}
+		epi.name = *rec.Name
+	}
+	if rec.RomajiName != nil {
+		epi.rjname = *rec.RomajiName
+	}
+	if rec.JPName != nil {
+		epi.jpname = *rec.JPName
+	}
+	if rec.Date != nil {
+		pdate, err := ParseDate(*rec.Date)
+		if err != nil {
+			return epi, fmt.Errorf("invalid date %q: %w", *rec.Date, err)
+		}
+		epi.date = pdate
+	}
+	if rec.Notes != nil {
+		epi.notes = *rec.Notes
+	}
+	return epi, nil
+}
+
+// Change is a single difference between two episode tables. Field is empty
+// when a whole episode was added or removed.
+type Change struct {
+	Production int
+	Field      string
+	Old, New   string
+}
+
+// Diff lists the differences between two tables, matching episodes by
+// production number.
+func Diff(base, changed *Table) []Change {
+	var ret []Change
+	old := make(map[int]Episode, len(base.episodes))
+	for _, epi := range base.episodes {
+		old[epi.production] = epi
+	}
+
+	for _, epi := range changed.episodes {
+		prev, ok := old[epi.production]
+		if !ok {
+			ret = append(ret, Change{Production: epi.production, New: epi.name})
+			continue
+		}
+		delete(old, epi.production)
+
+		before, after := prev.fields(), epi.fields()
+		for i, f := range fieldNames {
+			if before[i] != after[i] {
+				ret = append(ret, Change{Production: epi.production, Field: f, Old: before[i], New: after[i]})
+			}
+		}
+	}
+
+	var removed []Change
+	for prod, epi := range old {
+		removed = append(removed, Change{Production: prod, Old: epi.name})
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i].Production < removed[j].Production })
+
+	return append(ret, removed...)
+}
+
+var fieldNames = []string{"nettohen", "broadcast", "viz", "name", "rjname", "jpname", "date", "notes"}
+
+func (e Episode) fields() []string {
+	return []string{
+		strconv.Itoa(e.nettohen),
+		strconv.Itoa(e.broadcast),
+		strconv.Itoa(e.viz),
+		e.name,
+		e.rjname,
+		e.jpname,
+		JPDate(e.date),
+		e.notes,
+	}
+}
@@ -0,0 +1,465 @@
+package ranma
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Query is a parsed episode search such as
+//
+//	bc>=40 and bc<60 and title~"Happosai"
+//
+// Comparisons are made on a field and joined with and, or, not and
+// parentheses. The numeric fields are nh, bc, viz, prod and year; they
+// support =, !=, <, <=, > and >=, and never match episodes that have no
+// number in that ordering. date takes the same operators with a YYYY-MM-DD
+// value. The text fields are name, rjname, jpname, notes, and title, which
+// is any of the three titles; they support = and != (ignoring case and
+// punctuation), and ~ and !~, which test for a substring as Field.Contains
+// does given a "string", or a regular expression match given a /regexp/
+// (with an optional trailing i to ignore case). series compares with
+// original or nettohen.
+type Query struct {
+	root queryNode
+}
+
+// QueryError is a syntax error in a query. Column counts runes from 1.
+type QueryError struct {
+	Column int
+	Msg    string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column, e.Msg)
+}
+
+// Match reports whether e matches the query.
+func (q *Query) Match(e Episode) bool {
+	return q.root.match(e)
+}
+
+// ParseQuery parses a query. Errors are of type *QueryError.
+func ParseQuery(s string) (*Query, error) {
+	toks, err := lexQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{toks: toks}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, &QueryError{tok.col, fmt.Sprintf("unexpected %s", tok)}
+	}
+	return &Query{root: root}, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokDate
+	tokString
+	tokRegexp
+	tokOp
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	flags string // for regexps
+	col   int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokString:
+		return strconv.Quote(t.text)
+	case tokRegexp:
+		return "/" + t.text + "/" + t.flags
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+var datePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}`)
+
+func lexQuery(s string) ([]token, error) {
+	in := []rune(s)
+	var toks []token
+	i := 0
+	for i < len(in) {
+		r := in[i]
+		col := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			toks = append(toks, token{kind: tokLParen, text: "(", col: col})
+			i++
+		case r == ')':
+			toks = append(toks, token{kind: tokRParen, text: ")", col: col})
+			i++
+		case r == '&' && i+1 < len(in) && in[i+1] == '&':
+			toks = append(toks, token{kind: tokAnd, text: "&&", col: col})
+			i += 2
+		case r == '|' && i+1 < len(in) && in[i+1] == '|':
+			toks = append(toks, token{kind: tokOr, text: "||", col: col})
+			i += 2
+		case strings.ContainsRune("=!<>~", r):
+			j := i + 1
+			if j < len(in) && (in[j] == '=' || (r == '!' && in[j] == '~')) {
+				j++
+			}
+			op := string(in[i:j])
+			if op == "!" {
+				toks = append(toks, token{kind: tokNot, text: op, col: col})
+			} else {
+				toks = append(toks, token{kind: tokOp, text: op, col: col})
+			}
+			i = j
+		case r == '"':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(in) && in[j] != '"'; j++ {
+				if in[j] == '\\' && j+1 < len(in) {
+					j++
+				}
+				b.WriteRune(in[j])
+			}
+			if j >= len(in) {
+				return nil, &QueryError{col, "unterminated string"}
+			}
+			toks = append(toks, token{kind: tokString, text: b.String(), col: col})
+			i = j + 1
+		case r == '/':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(in) && in[j] != '/'; j++ {
+				if in[j] == '\\' && j+1 < len(in) && in[j+1] == '/' {
+					j++
+				}
+				b.WriteRune(in[j])
+			}
+			if j >= len(in) {
+				return nil, &QueryError{col, "unterminated regular expression"}
+			}
+			j++
+			flags := j
+			for j < len(in) && unicode.IsLetter(in[j]) {
+				if in[j] != 'i' {
+					return nil, &QueryError{j + 1, fmt.Sprintf("unknown regular expression flag %q", in[j])}
+				}
+				j++
+			}
+			toks = append(toks, token{kind: tokRegexp, text: b.String(), flags: string(in[flags:j]), col: col})
+			i = j
+		case unicode.IsDigit(r):
+			if m := datePattern.FindString(string(in[i:])); m != "" {
+				toks = append(toks, token{kind: tokDate, text: m, col: col})
+				i += len(m)
+				break
+			}
+			j := i
+			for j < len(in) && unicode.IsDigit(in[j]) {
+				j++
+			}
+			toks = append(toks, token{kind: tokNumber, text: string(in[i:j]), col: col})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(in) && (unicode.IsLetter(in[j]) || unicode.IsDigit(in[j]) || in[j] == '_') {
+				j++
+			}
+			word := string(in[i:j])
+			kind := tokIdent
+			switch strings.ToLower(word) {
+			case "and":
+				kind = tokAnd
+			case "or":
+				kind = tokOr
+			case "not":
+				kind = tokNot
+			}
+			toks = append(toks, token{kind: kind, text: word, col: col})
+			i = j
+		default:
+			return nil, &QueryError{col, fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+	return append(toks, token{kind: tokEOF, col: len(in) + 1}), nil
+}
+
+type queryParser struct {
+	toks []token
+	pos  int
+}
+
+func (p *queryParser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *queryParser) next() token {
+	tok := p.toks[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNot:
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner}, nil
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, &QueryError{closing.col, fmt.Sprintf("expected \")\", found %s", closing)}
+		}
+		return inner, nil
+	case tokIdent:
+		return p.parseComparison(tok)
+	}
+	return nil, &QueryError{tok.col, fmt.Sprintf("expected a field, \"not\" or \"(\", found %s", tok)}
+}
+
+func (p *queryParser) parseComparison(field token) (queryNode, error) {
+	op := p.next()
+	if op.kind != tokOp {
+		return nil, &QueryError{op.col, fmt.Sprintf("expected an operator after %s, found %s", field, op)}
+	}
+	val := p.next()
+
+	name := strings.ToLower(field.text)
+	if o, err := ParseOrder(name); err == nil {
+		return numberComparison(op, val, func(e Episode) int { return o.Number(e) })
+	}
+
+	switch name {
+	case "year":
+		return numberComparison(op, val, func(e Episode) int { return e.date.Year() })
+	case "date":
+		if val.kind != tokDate && val.kind != tokString {
+			return nil, &QueryError{val.col, fmt.Sprintf("expected a date, found %s", val)}
+		}
+		date, err := ParseDate(val.text)
+		if err != nil {
+			return nil, &QueryError{val.col, fmt.Sprintf("bad date %s", val)}
+		}
+		cmp, err := comparer(op)
+		if err != nil {
+			return nil, err
+		}
+		return funcNode(func(e Episode) bool {
+			d := dayOf(e.date)
+			switch {
+			case d.Before(date):
+				return cmp(-1)
+			case d.After(date):
+				return cmp(1)
+			}
+			return cmp(0)
+		}), nil
+	case "series":
+		if val.kind != tokIdent && val.kind != tokString {
+			return nil, &QueryError{val.col, fmt.Sprintf("expected original or nettohen, found %s", val)}
+		}
+		var nettohen bool
+		switch strings.ToLower(val.text) {
+		case "original":
+		case "nettohen":
+			nettohen = true
+		default:
+			return nil, &QueryError{val.col, fmt.Sprintf("expected original or nettohen, found %s", val)}
+		}
+		switch op.text {
+		case "=":
+			return funcNode(func(e Episode) bool { return e.IsNettohen() == nettohen }), nil
+		case "!=":
+			return funcNode(func(e Episode) bool { return e.IsNettohen() != nettohen }), nil
+		}
+		return nil, &QueryError{op.col, fmt.Sprintf("series can only be compared with = or !=, not %s", op)}
+	case "name", "title", "rjname", "jpname", "notes":
+		return textComparison(name, op, val)
+	}
+
+	return nil, &QueryError{field.col, fmt.Sprintf("unknown field %s", field)}
+}
+
+// comparer turns an ordering operator into a test on the sign of a
+// comparison.
+func comparer(op token) (func(int) bool, error) {
+	switch op.text {
+	case "=":
+		return func(c int) bool { return c == 0 }, nil
+	case "!=":
+		return func(c int) bool { return c != 0 }, nil
+	case "<":
+		return func(c int) bool { return c < 0 }, nil
+	case "<=":
+		return func(c int) bool { return c <= 0 }, nil
+	case ">":
+		return func(c int) bool { return c > 0 }, nil
+	case ">=":
+		return func(c int) bool { return c >= 0 }, nil
+	}
+	return nil, &QueryError{op.col, fmt.Sprintf("%s can't be used with this field", op)}
+}
+
+func numberComparison(op, val token, number func(Episode) int) (queryNode, error) {
+	if val.kind != tokNumber {
+		return nil, &QueryError{val.col, fmt.Sprintf("expected a number, found %s", val)}
+	}
+	n, err := strconv.Atoi(val.text)
+	if err != nil {
+		return nil, &QueryError{val.col, fmt.Sprintf("bad number %s", val)}
+	}
+	cmp, err := comparer(op)
+	if err != nil {
+		return nil, err
+	}
+	return funcNode(func(e Episode) bool {
+		m := number(e)
+		if m < 1 {
+			return false
+		}
+		switch {
+		case m < n:
+			return cmp(-1)
+		case m > n:
+			return cmp(1)
+		}
+		return cmp(0)
+	}), nil
+}
+
+// textSource is a piece of text a query can compare against, with the field
+// whose folding rules apply to it.
+type textSource struct {
+	field Field
+	text  func(Episode) string
+}
+
+func textComparison(name string, op, val token) (queryNode, error) {
+	var sources []textSource
+	switch name {
+	case "title":
+		for _, f := range Fields {
+			sources = append(sources, textSource{f, f.Text})
+		}
+	case "name":
+		sources = []textSource{{FieldName, FieldName.Text}}
+	case "rjname":
+		sources = []textSource{{FieldRomaji, FieldRomaji.Text}}
+	case "jpname":
+		sources = []textSource{{FieldJapanese, FieldJapanese.Text}}
+	case "notes":
+		sources = []textSource{{FieldName, Episode.Notes}}
+	}
+
+	var test func(src textSource, e Episode) bool
+	switch {
+	case val.kind == tokRegexp && (op.text == "~" || op.text == "!~"):
+		expr := val.text
+		if strings.Contains(val.flags, "i") {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, &QueryError{val.col, fmt.Sprintf("bad regular expression: %v", err)}
+		}
+		test = func(src textSource, e Episode) bool { return re.MatchString(src.text(e)) }
+	case val.kind == tokString && (op.text == "~" || op.text == "!~"):
+		test = func(src textSource, e Episode) bool { return src.field.contains(src.text(e), val.text) }
+	case val.kind == tokString && (op.text == "=" || op.text == "!="):
+		test = func(src textSource, e Episode) bool {
+			return strings.Join(src.field.words(src.text(e)), "") == strings.Join(src.field.words(val.text), "")
+		}
+	case val.kind != tokString && val.kind != tokRegexp:
+		return nil, &QueryError{val.col, fmt.Sprintf("expected a \"string\" or /regexp/, found %s", val)}
+	default:
+		return nil, &QueryError{op.col, fmt.Sprintf("%s can't be used with this value", op)}
+	}
+
+	negate := strings.HasPrefix(op.text, "!")
+	return funcNode(func(e Episode) bool {
+		for _, src := range sources {
+			if test(src, e) {
+				return !negate
+			}
+		}
+		return negate
+	}), nil
+}
+
+type queryNode interface {
+	match(Episode) bool
+}
+
+type funcNode func(Episode) bool
+
+func (f funcNode) match(e Episode) bool { return f(e) }
+
+type andNode struct{ left, right queryNode }
+
+func (n andNode) match(e Episode) bool { return n.left.match(e) && n.right.match(e) }
+
+type orNode struct{ left, right queryNode }
+
+func (n orNode) match(e Episode) bool { return n.left.match(e) || n.right.match(e) }
+
+type notNode struct{ inner queryNode }
+
+func (n notNode) match(e Episode) bool { return !n.inner.match(e) }
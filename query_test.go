@@ -0,0 +1,83 @@
+package ranma
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	table, err := Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  []int // broadcast numbers
+	}{
+		{`bc>=40 and bc<45`, []int{40, 41, 42, 43, 44}},
+		{`nh=1`, []int{19}},
+		{`nh<1`, nil},
+		{`not series=nettohen and bc>16`, []int{17, 18}},
+		{`year=1989 and (bc<3 or bc>30)`, []int{1, 2}},
+		{`date=1989-04-15`, []int{1}},
+		{`name="heres ranma"`, []int{1}},
+		{`name~"kiss"`, []int{39, 53, 82, 143}},
+		{`title~"too"`, []int{58, 79, 120}},
+		{`title~"zzzz"`, nil},
+		{`rjname~"ryoga" and bc<30`, []int{7, 8, 29}},
+		{`rjname~"ryouga" and bc<30`, []int{7, 8, 29}},
+		{`rjname~"Ryōga" and bc<30`, []int{7, 8, 29}},
+		{`rjname~"chuugoku"`, []int{1, 18}},
+		{`rjname~"sentou"`, []int{40}},
+		{`jpname~"らんま"`, []int{4, 14, 18, 82}},
+		{`name~/^the abduction/i`, []int{25, 31}},
+		{`name!~/e/ and bc<3`, nil},
+	}
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Errorf("ParseQuery(%q): %v", tt.query, err)
+			continue
+		}
+		var got []int
+		for _, epi := range table.Filter(q.Match) {
+			got = append(got, epi.Broadcast())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q matched %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query  string
+		column int
+	}{
+		{`bc>`, 4},
+		{`bc>=x`, 5},
+		{`foo=1`, 1},
+		{`nh=-1`, 4},
+		{`bc=1 and`, 9},
+		{`(bc=1`, 6},
+		{`bc=1 bc=2`, 6},
+		{`name~/[/`, 6},
+		{`name~/x/g`, 9},
+		{`name~/x/and bc=1`, 9},
+		{`name<"x"`, 5},
+		{`name~"unterminated`, 6},
+	}
+	for _, tt := range tests {
+		_, err := ParseQuery(tt.query)
+		var qerr *QueryError
+		if !errors.As(err, &qerr) {
+			t.Errorf("ParseQuery(%q) = %v, want a *QueryError", tt.query, err)
+			continue
+		}
+		if qerr.Column != tt.column {
+			t.Errorf("ParseQuery(%q) error at column %d, want %d (%v)", tt.query, qerr.Column, tt.column, qerr)
+		}
+	}
+}
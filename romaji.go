@@ -0,0 +1,78 @@
+package ranma
+
+import (
+	"strings"
+	"unicode"
+)
+
+// circumflexes are the other common way of writing long vowels.
+var circumflexes = strings.NewReplacer("â", "a", "ê", "e", "î", "i", "ô", "o", "û", "u")
+
+// romajiParticles maps the particle spellings that vary between
+// romanisations to a single form.
+var romajiParticles = map[string]string{
+	"wo": "o",
+	"ha": "wa",
+	"he": "e",
+}
+
+// NormalizeRomaji folds a romaji title for matching so that the different
+// Hepburn spellings compare equal: long vowels (ō, ô, ou, oo, oh, ū, uu and
+// so on) become a single vowel, the particles wo/o, wa/ha and e/he are
+// unified, double consonants and tch become single, and m before b or p
+// becomes n. Words are separated by single spaces.
+func NormalizeRomaji(s string) string {
+	s = circumflexes.Replace(strings.ToLower(s))
+	var words []string
+	for _, w := range strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if w = Fold(w); w == "" {
+			continue
+		}
+		if p, ok := romajiParticles[w]; ok {
+			w = p
+		} else {
+			w = normalizeRomajiWord(w)
+		}
+		words = append(words, w)
+	}
+	return strings.Join(words, " ")
+}
+
+func isVowel(c byte) bool {
+	return c == 'a' || c == 'e' || c == 'i' || c == 'o' || c == 'u'
+}
+
+// normalizeRomajiWord works on a word that Fold has already reduced to the
+// letters a-z.
+func normalizeRomajiWord(w string) string {
+	w = strings.ReplaceAll(w, "tch", "ch")
+	out := make([]byte, 0, len(w))
+	for i := 0; i < len(w); i++ {
+		c := w[i]
+		var last byte
+		if len(out) > 0 {
+			last = out[len(out)-1]
+		}
+
+		switch {
+		case isVowel(c) && c == last:
+			// aa, ii, uu, ee, oo
+			continue
+		case c == 'u' && last == 'o':
+			// ou
+			continue
+		case c == 'h' && last == 'o' && (i+1 == len(w) || !isVowel(w[i+1]) && w[i+1] != 'y'):
+			// oh, but not in e.g. "ohayo"
+			continue
+		case !isVowel(c) && c == last:
+			// kk, ss, tt, pp and so on
+			continue
+		case c == 'm' && i+1 < len(w) && (w[i+1] == 'b' || w[i+1] == 'p'):
+			c = 'n'
+		}
+		out = append(out, c)
+	}
+	return string(out)
+}
@@ -0,0 +1,30 @@
+package ranma
+
+import "testing"
+
+func TestNormalizeRomaji(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Chūgoku", "chugoku"},
+		{"Chuugoku", "chugoku"},
+		{"Chûgoku", "chugoku"},
+		{"Ryōga", "ryoga"},
+		{"Ryouga", "ryoga"},
+		{"Ryoga", "ryoga"},
+		{"Sentō", "sento"},
+		{"Sentou", "sento"},
+		{"Akane wo Sagase", "akane o sagase"},
+		{"Akane o Sagase", "akane o sagase"},
+		{"Kotchi", "kochi"},
+		{"Kappa", "kapa"},
+		{"Shimbun", "shinbun"},
+		{"Ohayo", "ohayo"},
+		{"Oh, Tendo!", "o tendo"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeRomaji(tt.in); got != tt.want {
+			t.Errorf("NormalizeRomaji(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package ranma
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Field is one of an episode's titles.
+type Field int
+
+const (
+	FieldName     Field = iota // English title
+	FieldRomaji                // Japanese title in romaji
+	FieldJapanese              // Japanese title
+)
+
+// Fields lists every title field.
+var Fields = []Field{FieldName, FieldRomaji, FieldJapanese}
+
+func (f Field) String() string {
+	switch f {
+	case FieldName:
+		return "name"
+	case FieldRomaji:
+		return "rjname"
+	case FieldJapanese:
+		return "jpname"
+	}
+	return "unknown"
+}
+
+// ParseField parses a field's name: name, rjname or jpname.
+func ParseField(s string) (Field, error) {
+	for _, f := range Fields {
+		if strings.EqualFold(s, f.String()) {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown field %q (want name, rjname or jpname)", s)
+}
+
+// Text returns the title of e that f refers to.
+func (f Field) Text(e Episode) string {
+	switch f {
+	case FieldRomaji:
+		return e.rjname
+	case FieldJapanese:
+		return e.jpname
+	}
+	return e.name
+}
+
+// Contains reports whether e's title contains query, ignoring case,
+// punctuation and accents. The query can't run across the end of a word.
+// In romaji a long vowel matches however it is spelled (ō, ô or ou; ū, û or
+// uu), and a plain vowel in the query matches a long one too, but unlike
+// Search nothing else is folded: "Ryouga" finds "Ryōga", but "too" doesn't
+// find "Chotto". "oo" is left as it is, since in a query it is far more
+// often English than romaji.
+func (f Field) Contains(e Episode, query string) bool {
+	return f.contains(f.Text(e), query)
+}
+
+func (f Field) contains(text, query string) bool {
+	q, s := f.substringText(query), f.substringText(text)
+	if q == "" {
+		return false
+	}
+	for i := 0; i+len(q) <= len(s); i++ {
+		if substringAt(s[i:], q) {
+			return true
+		}
+	}
+	return false
+}
+
+// substringAt reports whether s starts with q, where a plain vowel in q
+// also matches the same vowel marked long in s.
+func substringAt(s, q string) bool {
+	for i := 0; i < len(q); i++ {
+		if s[i] != q[i] && !(isVowel(q[i]) && s[i] == q[i]-'a'+'A') {
+			return false
+		}
+	}
+	return true
+}
+
+// romajiLongVowels marks the spellings of long vowels by making them upper
+// case, once a word has been lowercased.
+var romajiLongVowels = strings.NewReplacer(
+	"ā", "A", "â", "A", "aa", "A",
+	"ē", "E", "ê", "E",
+	"ī", "I", "î", "I",
+	"ō", "O", "ô", "O", "ou", "O",
+	"ū", "U", "û", "U", "uu", "U",
+)
+
+// substringText folds s for Contains, keeping a space between words. Only
+// substringAt should compare the results, since long romaji vowels are
+// marked in upper case.
+func (f Field) substringText(s string) string {
+	switch f {
+	case FieldJapanese:
+		return strings.Join(f.words(s), " ")
+	case FieldRomaji:
+		var ret []string
+		for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			w = strings.Map(func(r rune) rune {
+				if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+					return r
+				}
+				return -1
+			}, romajiLongVowels.Replace(w))
+			if w != "" {
+				ret = append(ret, w)
+			}
+		}
+		return strings.Join(ret, " ")
+	}
+
+	var ret []string
+	for _, w := range strings.FieldsFunc(circumflexes.Replace(strings.ToLower(s)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if w = Fold(w); w != "" {
+			ret = append(ret, w)
+		}
+	}
+	return strings.Join(ret, " ")
+}
+
+// words splits a title into folded words for matching.
+func (f Field) words(s string) []string {
+	switch f {
+	case FieldJapanese:
+		return strings.Fields(NormalizeJapanese(s))
+	case FieldRomaji:
+		return strings.Fields(NormalizeRomaji(s))
+	}
+
+	var ret []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if w = Fold(w); w != "" {
+			ret = append(ret, w)
+		}
+	}
+	return ret
+}
+
+// Match is a search result. Score runs from 0 to 1, where 1 is an exact
+// match once case and punctuation are ignored.
+type Match struct {
+	Episode Episode
+	Score   float64
+}
+
+// Search scores every episode's title against query and returns those that
+// match at all, best first.
+func (t *Table) Search(query string, field Field) []Match {
+	qwords := field.words(query)
+	if len(qwords) == 0 {
+		return nil
+	}
+
+	var ret []Match
+	for _, epi := range t.episodes {
+		if score := matchScore(qwords, field.words(field.Text(epi))); score > 0 {
+			ret = append(ret, Match{Episode: epi, Score: score})
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].Score > ret[j].Score })
+	return ret
+}
+
+// matchScore takes the best of three ways of comparing a query to a title:
+// the query appearing verbatim in the title, the query's words each
+// resembling a word of the title, and the edit distance between the two.
+func matchScore(query, title []string) float64 {
+	q, s := strings.Join(query, ""), strings.Join(title, "")
+	if s == "" {
+		return 0
+	}
+	if q == s {
+		return 1
+	}
+
+	best := 0.0
+	if strings.Contains(s, q) {
+		best = 0.75 + 0.2*float64(len(q))/float64(len(s))
+	}
+
+	words := 0.0
+	for _, qw := range query {
+		wbest := 0.0
+		for _, tw := range title {
+			wbest = math.Max(wbest, wordScore(qw, tw))
+		}
+		words += wbest
+	}
+	best = math.Max(best, 0.85*words/float64(len(query)))
+
+	if sim := similarity(q, s); sim >= 0.6 {
+		best = math.Max(best, 0.8*sim)
+	}
+
+	return best
+}
+
+func wordScore(query, word string) float64 {
+	switch {
+	case query == word:
+		return 1
+	case len(query) >= 3 && strings.HasPrefix(word, query):
+		return 0.9
+	}
+	if sim := similarity(query, word); sim >= 0.6 {
+		return sim
+	}
+	return 0
+}
+
+// similarity is 1 minus the edit distance between a and b relative to the
+// longer of the two.
+func similarity(a, b string) float64 {
+	ar, br := []rune(a), []rune(b)
+	longest := len(ar)
+	if len(br) > longest {
+		longest = len(br)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(Distance(a, b))/float64(longest)
+}
+
+// Distance returns the Levenshtein edit distance between a and b.
+func Distance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(br)]
+}
+
+// Suggest returns the n episodes whose titles come closest to query by edit
+// distance, for when a search finds nothing. Each title is compared a run of
+// words at a time, so a short query can still be close to a long title.
+func (t *Table) Suggest(query string, field Field, n int) []Match {
+	qwords := field.words(query)
+	if len(qwords) == 0 {
+		return nil
+	}
+	q := strings.Join(qwords, "")
+
+	ret := make([]Match, 0, len(t.episodes))
+	for _, epi := range t.episodes {
+		title := field.words(field.Text(epi))
+		size := len(qwords)
+		if size > len(title) {
+			size = len(title)
+		}
+		best := 0.0
+		for i := 0; i+size <= len(title) && size > 0; i++ {
+			best = math.Max(best, similarity(q, strings.Join(title[i:i+size], "")))
+		}
+		ret = append(ret, Match{Episode: epi, Score: best})
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].Score > ret[j].Score })
+	if len(ret) > n {
+		ret = ret[:n]
+	}
+	return ret
+}
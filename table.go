@@ -0,0 +1,185 @@
+package ranma
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrNotFound is returned when no episode matches a lookup.
+var ErrNotFound = errors.New("cannot find matching episode")
+
+// Table is an ordered collection of episodes.
+type Table struct {
+	episodes []Episode
+}
+
+// Episodes returns a copy of the table's episodes in broadcast order.
+func (t *Table) Episodes() []Episode {
+	ret := make([]Episode, len(t.episodes))
+	copy(ret, t.episodes)
+	return ret
+}
+
+// Find returns the first episode for which matcher returns true.
+func (t *Table) Find(matcher func(Episode) bool) (*Episode, error) {
+	for _, epi := range t.episodes {
+		if matcher(epi) {
+			return &epi, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Filter returns every episode for which matcher returns true.
+func (t *Table) Filter(matcher func(Episode) bool) []Episode {
+	var ret []Episode
+	for _, epi := range t.episodes {
+		if matcher(epi) {
+			ret = append(ret, epi)
+		}
+	}
+	return ret
+}
+
+// ByNettohen finds an episode by its Nettohen number.
+func (t *Table) ByNettohen(n int) (*Episode, error) {
+	return t.By(OrderNettohen, n)
+}
+
+// ByBroadcast finds an episode by its position in broadcast order.
+func (t *Table) ByBroadcast(n int) (*Episode, error) {
+	return t.By(OrderBroadcast, n)
+}
+
+// ByViz finds an episode by its position in the Viz home release order.
+func (t *Table) ByViz(n int) (*Episode, error) {
+	return t.By(OrderViz, n)
+}
+
+// ByProduction finds an episode by its position in production order.
+func (t *Table) ByProduction(n int) (*Episode, error) {
+	return t.By(OrderProduction, n)
+}
+
+// ByName finds an episode by its English title. Case, punctuation and
+// macrons are ignored.
+func (t *Table) ByName(name string) (*Episode, error) {
+	fuzz := Fold(name)
+	return t.Find(func(ep Episode) bool { return Fold(ep.name) == fuzz })
+}
+
+// ByRomajiName finds an episode by its romaji title. Case, punctuation and
+// differences between Hepburn spellings are ignored; see NormalizeRomaji.
+func (t *Table) ByRomajiName(name string) (*Episode, error) {
+	fuzz := NormalizeRomaji(name)
+	return t.Find(func(ep Episode) bool { return NormalizeRomaji(ep.rjname) == fuzz })
+}
+
+// Episodes returns the built-in episodes in broadcast order.
+func Episodes() ([]Episode, error) {
+	t, err := Builtin()
+	if err != nil {
+		return nil, err
+	}
+	return t.Episodes(), nil
+}
+
+// Find returns the first built-in episode for which matcher returns true.
+func Find(matcher func(Episode) bool) (*Episode, error) {
+	t, err := Builtin()
+	if err != nil {
+		return nil, err
+	}
+	return t.Find(matcher)
+}
+
+// ByNettohen finds a built-in episode by its Nettohen number.
+func ByNettohen(n int) (*Episode, error) {
+	return by(OrderNettohen, n)
+}
+
+// ByBroadcast finds a built-in episode by its position in broadcast order.
+func ByBroadcast(n int) (*Episode, error) {
+	return by(OrderBroadcast, n)
+}
+
+// ByViz finds a built-in episode by its position in the Viz release order.
+func ByViz(n int) (*Episode, error) {
+	return by(OrderViz, n)
+}
+
+// ByProduction finds a built-in episode by its position in production order.
+func ByProduction(n int) (*Episode, error) {
+	return by(OrderProduction, n)
+}
+
+func by(o Order, n int) (*Episode, error) {
+	t, err := Builtin()
+	if err != nil {
+		return nil, err
+	}
+	return t.By(o, n)
+}
+
+// ByJapaneseName finds a built-in episode by its Japanese title.
+func ByJapaneseName(name string) (*Episode, error) {
+	t, err := Builtin()
+	if err != nil {
+		return nil, err
+	}
+	return t.ByJapaneseName(name)
+}
+
+// ByName finds a built-in episode by its English title.
+func ByName(name string) (*Episode, error) {
+	t, err := Builtin()
+	if err != nil {
+		return nil, err
+	}
+	return t.ByName(name)
+}
+
+// ByRomajiName finds a built-in episode by its romaji title.
+func ByRomajiName(name string) (*Episode, error) {
+	t, err := Builtin()
+	if err != nil {
+		return nil, err
+	}
+	return t.ByRomajiName(name)
+}
+
+// ByJapaneseName finds an episode by its Japanese title. Character widths,
+// the difference between hiragana and katakana, and punctuation are
+// ignored.
+func (t *Table) ByJapaneseName(name string) (*Episode, error) {
+	fuzz := strings.ReplaceAll(NormalizeJapanese(name), " ", "")
+	return t.Find(func(ep Episode) bool {
+		return strings.ReplaceAll(NormalizeJapanese(ep.jpname), " ", "") == fuzz
+	})
+}
+
+// Fold lowercases s and strips everything but the letters a-z, mapping
+// macron vowels to their plain counterparts.
+func Fold(s string) string {
+	return strings.Map(fuzzy, strings.ToLower(s))
+}
+
+func fuzzy(r rune) rune {
+	if r >= 'a' && r <= 'z' {
+		return r
+	}
+	if r > 0x0100 && r < 0x0170 {
+		if r == 0x0101 {
+			return 'a'
+		} else if r == 0x0113 {
+			return 'e'
+		} else if r == 0x012B {
+			return 'i'
+		} else if r == 0x014D {
+			return 'o'
+		} else if r == 0x016B {
+			return 'u'
+		}
+	}
+	return -1
+}